@@ -2,6 +2,7 @@ package backend
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -15,20 +16,31 @@ import (
 
 // Server represents the HTTP server
 type Server struct {
-	port     int
-	basePath string
-	db       string // Add database connection string
+	port         int
+	basePath     string
+	db           string // Add database connection string
+	layoutConfig waterfall.LayoutConfig
 }
 
 // NewServer creates a new server instance
 func NewServer(port int, basePath string, dbDSN string) *Server {
 	return &Server{
-		port:     port,
-		basePath: basePath,
-		db:       dbDSN,
+		port:         port,
+		basePath:     basePath,
+		db:           dbDSN,
+		layoutConfig: waterfall.DefaultLayoutConfig(),
 	}
 }
 
+// SetLayoutConfig replaces the layout configuration used for every request.
+func (s *Server) SetLayoutConfig(cfg waterfall.LayoutConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	s.layoutConfig = cfg
+	return nil
+}
+
 // Start starts the HTTP server
 func (s *Server) Start() error {
 	// Initialize real data
@@ -42,6 +54,14 @@ func (s *Server) Start() error {
 
 	// API endpoints
 	http.HandleFunc("/continuous-layout-real", s.handleContinuousLayoutReal)
+	http.HandleFunc("/template-preview", s.handleTemplatePreview)
+	http.HandleFunc("/explain", s.handleExplain)
+
+	// Serve emoticon and emoji images used as inline glyphs
+	if emoticons := s.layoutConfig.Emoticons; emoticons.Enabled && emoticons.Dir != "" {
+		prefix := strings.TrimSuffix(emoticons.URLPrefix, "/") + "/"
+		http.Handle(prefix, http.StripPrefix(prefix, http.FileServer(http.Dir(emoticons.Dir))))
+	}
 
 	// Start server
 	addr := fmt.Sprintf(":%d", s.port)
@@ -64,26 +84,129 @@ func (s *Server) handleContinuousLayoutReal(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	// trace=1 记录每个条目的布局决策并随结果返回；strict=1 遇到降级排版时直接失败
+	cfg := s.layoutConfig
+	if trace, err := strconv.ParseBool(r.URL.Query().Get("trace")); err == nil {
+		cfg.Trace = trace
+	}
+	if strict, err := strconv.ParseBool(r.URL.Query().Get("strict")); err == nil {
+		cfg.Strict = strict
+	}
+
+	allPages, layoutTrace, err := s.layoutRealData(cfg, "")
+	var layoutErr *waterfall.LayoutError
+	if errors.As(err, &layoutErr) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error":    layoutErr.Error(),
+			"entry_id": layoutErr.EntryID,
+			"page":     layoutErr.Page,
+			"pictures": layoutErr.Pictures,
+		})
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// 将所有页面的坐标转换为输出DPI（默认72DPI）
+	scale := s.layoutConfig.OutputScale()
+	for i := range allPages {
+		allPages[i] = convertPageToOutputDPI(allPages[i], scale)
+	}
+	pageWidth, pageHeight, _ := s.layoutConfig.PageDimensions()
+
+	response := map[string]interface{}{
+		"pages":       allPages,
+		"page_width":  pageWidth * scale,
+		"page_height": pageHeight * scale,
+	}
+	if cfg.Trace {
+		response["trace"] = layoutTrace
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleExplain explains the layout of one moment: it lays out the moment's month with
+// tracing and returns, for every block of its pictures, the template candidates with
+// their scores and minimum height checks and the rule that placed the block. The
+// months before it are laid out in full as well, untraced, since their page count
+// decides the month's first page number and so which side its pages are on; a request
+// costs as much as laying out everything up to the moment's month.
+func (s *Server) handleExplain(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "id: a moment ID is required", http.StatusBadRequest)
+		return
+	}
+	element, ok := RealData[id]
+	if !ok {
+		http.Error(w, fmt.Sprintf("moment %d not found", id), http.StatusNotFound)
+		return
+	}
+	yearMonthKey := getYearMonthKey(element.Time)
+	if yearMonthKey == "" {
+		http.Error(w, fmt.Sprintf("moment %d has no valid time and is not laid out", id), http.StatusNotFound)
+		return
+	}
+
+	// 前面的月份决定该月的起始页码（左右页），只追踪该月；降级的排版也要能解释，不用strict
+	cfg := s.layoutConfig
+	cfg.Strict = false
+	_, layoutTrace, err := s.layoutRealData(cfg, yearMonthKey)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	explanation, ok := layoutTrace.Explain(int64(id))
+	if !ok {
+		http.Error(w, fmt.Sprintf("moment %d was not laid out", id), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"year_month":  yearMonthKey,
+		"explanation": explanation,
+	})
+}
+
+// layoutRealData lays out RealData month by month, newest first, with an insert page
+// before every month. Coordinates stay in layout DPI. With explainMonth ("2024-05")
+// set, only that month is traced and the months following it are not laid out;
+// otherwise every month is traced if cfg.Trace is set.
+func (s *Server) layoutRealData(cfg waterfall.LayoutConfig, explainMonth string) ([]waterfall.ContinuousLayoutPage, waterfall.LayoutTrace, error) {
+	var layoutTrace waterfall.LayoutTrace
+
 	// 获取所有ID并按降序排序
 	var ids []int
 	for id := range RealData {
 		ids = append(ids, id)
 	}
 
-	// 按时间降序排序
+	// 按时间降序排序，时间相同时按ID降序，保证每次请求顺序一致
 	sort.Slice(ids, func(i, j int) bool {
 		timeI, errI := time.Parse("2006-01-02 15:04:05", RealData[ids[i]].Time)
 		timeJ, errJ := time.Parse("2006-01-02 15:04:05", RealData[ids[j]].Time)
 
 		// 如果解析出错，将其放到最后
-		if errI != nil {
-			return false
+		if errI != nil || errJ != nil {
+			if (errI == nil) != (errJ == nil) {
+				return errI == nil
+			}
+			return ids[i] > ids[j]
 		}
-		if errJ != nil {
-			return true
+		if !timeI.Equal(timeJ) {
+			return timeI.After(timeJ)
 		}
-
-		return timeI.After(timeJ)
+		return ids[i] > ids[j]
 	})
 
 	// 按年月分组
@@ -132,64 +255,140 @@ func (s *Server) handleContinuousLayoutReal(w http.ResponseWriter, r *http.Reque
 		month, _ := strconv.Atoi(parts[1])
 		yearMonth := fmt.Sprintf("%d年%d月", year, month)
 
+		// 月份插页从右页开始
+		if s.layoutConfig.InsertsOnRecto {
+			allPages, pageNumber = waterfall.PadToRecto(allPages, pageNumber)
+		}
 		insertPage := waterfall.ContinuousLayoutPage{
 			Page:      pageNumber,
 			IsInsert:  true,
+			Side:      waterfall.PageSide(pageNumber),
 			YearMonth: yearMonth,
 			Entries:   []waterfall.PageEntry{},
 		}
 		allPages = append(allPages, insertPage)
 		pageNumber++
 
+		// 该月第一页内容从右页开始
+		if s.layoutConfig.ContentOnRecto {
+			allPages, pageNumber = waterfall.PadToRecto(allPages, pageNumber)
+		}
+
 		// 处理该年月的条目
-		engine := waterfall.NewContinuousLayoutEngine(entries)
-		pages, err := engine.ProcessEntries()
+		monthConfig := cfg
+		if explainMonth != "" {
+			monthConfig.Trace = yearMonthKey == explainMonth
+		}
+		pages, trace, err := waterfall.LayoutWithTrace(entries, monthConfig, pageNumber)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
+			return nil, layoutTrace, err
+		}
+		if trace != nil {
+			layoutTrace.PageBreaks = append(layoutTrace.PageBreaks, trace.PageBreaks...)
+			layoutTrace.Entries = append(layoutTrace.Entries, trace.Entries...)
 		}
 
-		// 更新页码并添加年月信息
+		// 页码已从pageNumber开始编号，这里只添加年月信息
 		for i := range pages {
-			pages[i].Page = pageNumber
 			pages[i].YearMonth = yearMonth
-			pageNumber++
 		}
+		pageNumber += len(pages)
 
 		allPages = append(allPages, pages...)
+		if yearMonthKey == explainMonth {
+			break
+		}
 	}
+	return allPages, layoutTrace, nil
+}
 
-	// 将所有页面的坐标转换为72DPI
-	for i := range allPages {
-		allPages[i] = convertPageTo72DPI(allPages[i])
+// handleTemplatePreview lays out one template for sample pictures, so template
+// definitions can be checked without running a whole book. The template is given by
+// name, or declaratively by rows ("2,3,2,1") or columns ("3|4"). ars lists the
+// pictures' aspect ratios (W/H); by default landscape 4:3 and portrait 3:4 alternate.
+func (s *Server) handleTemplatePreview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	query := r.URL.Query()
+
+	var template waterfall.LayoutTemplate
+	var err error
+	if name := query.Get("name"); name != "" {
+		template, err = waterfall.FindLayoutTemplate(s.layoutConfig, name)
+	} else {
+		template, err = waterfall.TemplateDefinition{
+			Name:    "preview",
+			Rows:    query.Get("rows"),
+			Columns: query.Get("columns"),
+		}.Template()
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ars := make([]float64, template.PictureCount())
+	for i := range ars {
+		ars[i] = 4.0 / 3.0
+		if i%2 == 1 {
+			ars[i] = 3.0 / 4.0
+		}
+	}
+	if param := query.Get("ars"); param != "" {
+		fields := strings.Split(param, ",")
+		if len(fields) != len(ars) {
+			http.Error(w, fmt.Sprintf("ars: template %s needs %d aspect ratios (got %d)", template.Name(), len(ars), len(fields)), http.StatusBadRequest)
+			return
+		}
+		for i, field := range fields {
+			if ars[i], err = strconv.ParseFloat(strings.TrimSpace(field), 64); err != nil {
+				http.Error(w, fmt.Sprintf("ars: invalid aspect ratio %q", field), http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	layout, err := waterfall.PreviewLayoutTemplate(s.layoutConfig, template, ars)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	// 将坐标转换为输出DPI
+	scale := s.layoutConfig.OutputScale()
+	areas := make([][][]float64, len(layout.Positions))
+	for i, pos := range layout.Positions {
+		dim := layout.Dimensions[i]
+		areas[i] = convertAreaToOutputDPI([][]float64{{pos[0], pos[1]}, {pos[0] + dim[0], pos[1] + dim[1]}}, scale)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"pages": allPages,
+		"name":          template.Name(),
+		"picture_count": template.PictureCount(),
+		"aspect_ratios": ars,
+		"width":         layout.TotalWidth * scale,
+		"height":        layout.TotalHeight * scale,
+		"areas":         areas,
 	})
 }
 
-// convertTo72DPI converts coordinates from 300DPI to 72DPI
-func convertTo72DPI(value float64) float64 {
-	return value * 72 / 300
-}
-
-// convertAreaTo72DPI converts an area's coordinates from 300DPI to 72DPI
-func convertAreaTo72DPI(area [][]float64) [][]float64 {
+// convertAreaToOutputDPI scales an area's coordinates from layout DPI to output DPI
+func convertAreaToOutputDPI(area [][]float64, scale float64) [][]float64 {
 	if len(area) != 2 || len(area[0]) != 2 || len(area[1]) != 2 {
 		return area
 	}
 	return [][]float64{
-		{convertTo72DPI(area[0][0]), convertTo72DPI(area[0][1])},
-		{convertTo72DPI(area[1][0]), convertTo72DPI(area[1][1])},
+		{area[0][0] * scale, area[0][1] * scale},
+		{area[1][0] * scale, area[1][1] * scale},
 	}
 }
 
-// convertPageTo72DPI converts all coordinates in a page from 300DPI to 72DPI
-func convertPageTo72DPI(page waterfall.ContinuousLayoutPage) waterfall.ContinuousLayoutPage {
+// convertPageToOutputDPI scales all coordinates in a page from layout DPI to output DPI
+func convertPageToOutputDPI(page waterfall.ContinuousLayoutPage, scale float64) waterfall.ContinuousLayoutPage {
 	// 不要转换页码，保持原样
-	// page.Page = int(convertTo72DPI(float64(page.Page)))
 
 	// Convert each entry
 	for i := range page.Entries {
@@ -197,19 +396,29 @@ func convertPageTo72DPI(page waterfall.ContinuousLayoutPage) waterfall.Continuou
 
 		// Convert time area
 		if entry.TimeArea != nil {
-			entry.TimeArea = convertAreaTo72DPI(entry.TimeArea)
+			entry.TimeArea = convertAreaToOutputDPI(entry.TimeArea, scale)
 		}
 
 		// Convert text areas
 		for j := range entry.TextAreas {
-			entry.TextAreas[j] = convertAreaTo72DPI(entry.TextAreas[j])
+			entry.TextAreas[j] = convertAreaToOutputDPI(entry.TextAreas[j], scale)
+		}
+
+		// Convert inline text runs and emoticons
+		for j := range entry.InlineRuns {
+			entry.InlineRuns[j].Area = convertAreaToOutputDPI(entry.InlineRuns[j].Area, scale)
 		}
 
 		// Convert pictures
 		for j := range entry.Pictures {
-			entry.Pictures[j].Area = convertAreaTo72DPI(entry.Pictures[j].Area)
+			entry.Pictures[j].Area = convertAreaToOutputDPI(entry.Pictures[j].Area, scale)
 		}
 	}
 
+	// Convert the available heights of rated template candidates
+	for i := range page.TemplateChoices {
+		page.TemplateChoices[i].AvailableHeight *= scale
+	}
+
 	return page
 }
@@ -0,0 +1,453 @@
+package waterfall
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LayoutConfig holds every tunable parameter of the continuous layout engine.
+// All lengths are in pixels at ReferenceDPI (300); the engine rescales them to DPI.
+type LayoutConfig struct {
+	// Preset names the preset a config file builds on. Fields present in the
+	// file override the preset values; it is ignored once the config is loaded.
+	Preset string `json:"preset,omitempty" yaml:"preset,omitempty"`
+
+	PageSize    string  `json:"page_size" yaml:"page_size"`     // catalog name or custom size, see ParsePageSize
+	Orientation string  `json:"orientation" yaml:"orientation"` // portrait or landscape
+	DPI         float64 `json:"dpi" yaml:"dpi"`                 // resolution of the layout coordinates
+	OutputDPI   float64 `json:"output_dpi" yaml:"output_dpi"`   // resolution the server converts coordinates to
+
+	MarginLeft   float64 `json:"margin_left" yaml:"margin_left"`
+	MarginRight  float64 `json:"margin_right" yaml:"margin_right"`
+	MarginTop    float64 `json:"margin_top" yaml:"margin_top"`
+	MarginBottom float64 `json:"margin_bottom" yaml:"margin_bottom"`
+
+	// Spread lays pages out as facing pairs. InnerMargin (binding side) and OuterMargin
+	// then replace MarginLeft/MarginRight and are mirrored between left and right pages.
+	Spread      bool    `json:"spread" yaml:"spread"`
+	InnerMargin float64 `json:"inner_margin" yaml:"inner_margin"`
+	OuterMargin float64 `json:"outer_margin" yaml:"outer_margin"`
+
+	// InsertsOnRecto starts every month insert page on a right-hand (odd) page, and
+	// ContentOnRecto does the same for the first content page of each month. Blank
+	// filler pages are added where needed.
+	InsertsOnRecto bool `json:"inserts_on_recto" yaml:"inserts_on_recto"`
+	ContentOnRecto bool `json:"content_on_recto" yaml:"content_on_recto"`
+
+	TimeHeight float64 `json:"time_height" yaml:"time_height"`
+	FontSize   float64 `json:"font_size" yaml:"font_size"`     // 66.67 对应72DPI的16px
+	LineHeight float64 `json:"line_height" yaml:"line_height"` // 100 对应72DPI的24px
+
+	// FontFile is a TrueType/OpenType font (.ttf, .otf, .ttc) used to measure text, e.g.
+	// NotoSansCJK-Regular.ttc; FontIndex selects the face in a collection. Without a font
+	// every character is measured as one em wide.
+	FontFile  string `json:"font_file" yaml:"font_file"`
+	FontIndex int    `json:"font_index" yaml:"font_index"`
+
+	// ContinuationHeader repeats the entry date with "（续）" in the top margin of pages
+	// where an entry continues. It needs margin_top >= time_height.
+	ContinuationHeader bool `json:"continuation_header" yaml:"continuation_header"`
+
+	// Orphans and Widows are the minimum numbers of lines of a paragraph kept at the
+	// bottom of a page and at the top of the next when it breaks across pages.
+	Orphans int `json:"orphans" yaml:"orphans"`
+	Widows  int `json:"widows" yaml:"widows"`
+
+	LineBreaking LineBreakRules `json:"line_breaking" yaml:"line_breaking"` // 中文断行规则
+	Emoticons    EmoticonConfig `json:"emoticons" yaml:"emoticons"`         // 表情代码和emoji图片
+
+	EntrySpacing   float64 `json:"entry_spacing" yaml:"entry_spacing"`     // 条目之间的间距
+	ElementSpacing float64 `json:"element_spacing" yaml:"element_spacing"` // 元素整体之间的间距
+	ImageSpacing   float64 `json:"image_spacing" yaml:"image_spacing"`     // 图片之间的间距
+
+	// KeepTogether moves content to the next page instead of splitting it.
+	KeepTogether KeepTogether `json:"keep_together" yaml:"keep_together"`
+
+	// VerticalJustification spreads the space left at page bottoms over the entry and
+	// element spacing.
+	VerticalJustification VerticalJustification `json:"vertical_justification" yaml:"vertical_justification"`
+
+	MinWideHeight       float64   `json:"min_wide_height" yaml:"min_wide_height"`             // Min height for Wide pics (AR >= 3)
+	MinTallHeight       float64   `json:"min_tall_height" yaml:"min_tall_height"`             // Min height for Tall pics (AR <= 1/3)
+	MinLandscapeHeights []float64 `json:"min_landscape_heights" yaml:"min_landscape_heights"` // 横图最小高度 (索引 0-8 对应 1-9 张图)
+	MinPortraitHeights  []float64 `json:"min_portrait_heights" yaml:"min_portrait_heights"`   // 竖图最小高度 (索引 0-8 对应 1-9 张图)
+
+	SingleImageHeight float64 `json:"single_image_height" yaml:"single_image_height"` // 单张竖图的最大高度
+	SingleImageWidth  float64 `json:"single_image_width" yaml:"single_image_width"`   // 单张横图的最大宽度
+
+	// OptimizePagination chooses the entries that start a new page, and whether an
+	// entry's pictures keep full scale or stay together, for all entries laid out
+	// together (a month on the server) instead of filling pages greedily, minimizing
+	// the whitespace at page bottoms and the relaxed constraints. PaginationWindow
+	// limits how many entries may flow between two chosen breaks, so a break is forced
+	// at least every PaginationWindow entries, and the cost of the pass, which grows
+	// with the window times the number of entries; 0 means no limit.
+	OptimizePagination bool `json:"optimize_pagination" yaml:"optimize_pagination"`
+	PaginationWindow   int  `json:"pagination_window" yaml:"pagination_window"`
+
+	// PictureLayout is "templates" (default) to lay out up to nine pictures with layout
+	// templates, or "justified" to lay out any number of pictures in full-width rows of
+	// equal height close to JustifiedRowHeight.
+	PictureLayout      string  `json:"picture_layout" yaml:"picture_layout"`
+	JustifiedRowHeight float64 `json:"justified_row_height" yaml:"justified_row_height"` // 等高行布局的目标行高
+	// PictureGroupSize is the largest group a picture set of more than nine pictures is
+	// partitioned into; each group is laid out with the templates for its size.
+	PictureGroupSize int `json:"picture_group_size" yaml:"picture_group_size"`
+	// TemplateDir holds template files (see TemplateDefinition) whose templates are
+	// considered in addition to the registered ones.
+	TemplateDir string `json:"template_dir" yaml:"template_dir"`
+	// DisabledTemplates lists layout templates, by name (e.g. "3L4R"), that are never
+	// chosen. See LayoutTemplates for the registered names.
+	DisabledTemplates []string `json:"disabled_templates" yaml:"disabled_templates"`
+	// Scorer picks the template among those meeting the minimum heights: area,
+	// uniformity, whitespace, reading_order, or weighted to mix them by ScorerWeights.
+	Scorer        string             `json:"scorer" yaml:"scorer"`
+	ScorerWeights map[string]float64 `json:"scorer_weights" yaml:"scorer_weights"` // 评分名 -> 权重
+	ExposeScores  bool               `json:"expose_scores" yaml:"expose_scores"`   // 在页面中输出每个候选模板的评分
+	// CropBudget is the largest share of a picture that may be cropped away on each
+	// side (0.15 = 15%) so it fits a template better. 0 disables cropping.
+	CropBudget float64 `json:"crop_budget" yaml:"crop_budget"`
+	// ImageDir holds local copies of the pictures, named like the last element of their
+	// URL. Pictures cropped without a Focus get one detected from these files.
+	ImageDir string `json:"image_dir" yaml:"image_dir"`
+	// Trace records why each entry was laid out the way it was: the template
+	// candidates, the split rules applied, placements and page breaks. See LayoutTrace.
+	Trace bool `json:"trace" yaml:"trace"`
+	// Strict fails the layout with a LayoutError instead of degrading content that
+	// cannot be laid out as configured, e.g. pictures below their minimum height.
+	Strict bool `json:"strict" yaml:"strict"`
+}
+
+// maxTemplatePictures is the number of entries expected in the per-count min height tables.
+const maxTemplatePictures = 9
+
+// DefaultLayoutConfig returns the configuration the engine has always used (A4 at 300 DPI).
+func DefaultLayoutConfig() LayoutConfig {
+	return LayoutConfig{
+		PageSize:    "A4",
+		Orientation: OrientationPortrait,
+		DPI:         ReferenceDPI,
+		OutputDPI:   72,
+
+		MarginLeft:     142,
+		MarginRight:    142,
+		MarginTop:      189,
+		MarginBottom:   189,
+		InnerMargin:    189,
+		OuterMargin:    142,
+		TimeHeight:     100,
+		FontSize:       66.67,
+		LineHeight:     100,
+		Orphans:        1,
+		Widows:         1,
+		LineBreaking:   DefaultLineBreakRules(),
+		Emoticons:      DefaultEmoticonConfig(),
+		EntrySpacing:   150,
+		ElementSpacing: 30,
+		ImageSpacing:   15,
+		MinWideHeight:  600,
+		MinTallHeight:  800,
+
+		MinLandscapeHeights: []float64{600, 600, 400, 600, 600, 600, 600, 600, 600},
+		MinPortraitHeights:  []float64{800, 800, 600, 800, 800, 800, 800, 800, 800},
+
+		SingleImageHeight: 3130,
+		SingleImageWidth:  2124,
+
+		PaginationWindow:      20,
+		KeepTogether:          DefaultKeepTogether(),
+		VerticalJustification: DefaultVerticalJustification(),
+
+		PictureLayout:      PictureLayoutTemplates,
+		JustifiedRowHeight: 700,
+		PictureGroupSize:   maxTemplatePictures,
+		Scorer:             ScorerArea,
+	}
+}
+
+// layoutPresets maps preset names to constructors. Each constructor returns a fresh
+// copy so callers can modify the result freely.
+var layoutPresets = map[string]func() LayoutConfig{
+	"default": DefaultLayoutConfig,
+	// compact: tighter margins and spacing, smaller minimum picture heights.
+	"compact": func() LayoutConfig {
+		cfg := DefaultLayoutConfig()
+		cfg.MarginLeft, cfg.MarginRight = 118, 118
+		cfg.MarginTop, cfg.MarginBottom = 142, 142
+		cfg.EntrySpacing = 100
+		cfg.ElementSpacing = 20
+		cfg.ImageSpacing = 10
+		cfg.VerticalJustification.MaxEntrySpacing = 200
+		cfg.VerticalJustification.MaxElementSpacing = 40
+		cfg.MinWideHeight = 480
+		cfg.MinTallHeight = 640
+		cfg.MinLandscapeHeights = []float64{480, 480, 320, 480, 480, 480, 480, 480, 480}
+		cfg.MinPortraitHeights = []float64{640, 640, 480, 640, 640, 640, 640, 640, 640}
+		return cfg
+	},
+	// spacious: wide margins and generous gaps, for photo-heavy books.
+	"spacious": func() LayoutConfig {
+		cfg := DefaultLayoutConfig()
+		cfg.MarginLeft, cfg.MarginRight = 189, 189
+		cfg.MarginTop, cfg.MarginBottom = 236, 236
+		cfg.EntrySpacing = 200
+		cfg.ElementSpacing = 40
+		cfg.ImageSpacing = 20
+		cfg.VerticalJustification.MaxEntrySpacing = 400
+		cfg.VerticalJustification.MaxElementSpacing = 80
+		return cfg
+	},
+}
+
+// LayoutConfigPresets returns the names of all built-in presets in sorted order.
+func LayoutConfigPresets() []string {
+	names := make([]string, 0, len(layoutPresets))
+	for name := range layoutPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LayoutConfigPreset returns the named built-in configuration.
+func LayoutConfigPreset(name string) (LayoutConfig, error) {
+	preset, ok := layoutPresets[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return LayoutConfig{}, fmt.Errorf("unknown layout preset %q (available: %s)", name, strings.Join(LayoutConfigPresets(), ", "))
+	}
+	cfg := preset()
+	cfg.Preset = name
+	return cfg, nil
+}
+
+// LoadLayoutConfig reads a JSON or YAML config file, chosen by file extension.
+func LoadLayoutConfig(path string) (LayoutConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return LayoutConfig{}, err
+	}
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	cfg, err := ParseLayoutConfig(data, format)
+	if err != nil {
+		return LayoutConfig{}, fmt.Errorf("layout config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// ParseLayoutConfig decodes a config document in the given format ("json", "yaml" or "yml").
+// Values missing from the document are taken from its preset, or from the default preset.
+// The result is validated before it is returned.
+func ParseLayoutConfig(data []byte, format string) (LayoutConfig, error) {
+	unmarshal, err := unmarshalerFor(format)
+	if err != nil {
+		return LayoutConfig{}, fmt.Errorf("unsupported layout config format %q", format)
+	}
+
+	// Read the preset first so the document is decoded on top of it.
+	var header struct {
+		Preset string `json:"preset" yaml:"preset"`
+	}
+	if err := unmarshal(data, &header); err != nil {
+		return LayoutConfig{}, err
+	}
+	cfg := DefaultLayoutConfig()
+	if header.Preset != "" {
+		preset, err := LayoutConfigPreset(header.Preset)
+		if err != nil {
+			return LayoutConfig{}, err
+		}
+		cfg = preset
+	}
+
+	if err := unmarshal(data, &cfg); err != nil {
+		return LayoutConfig{}, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return LayoutConfig{}, err
+	}
+	return cfg, nil
+}
+
+// unmarshalerFor returns the decoder for a config document format ("json", "yaml" or "yml").
+func unmarshalerFor(format string) (func([]byte, interface{}) error, error) {
+	switch format {
+	case "json":
+		return json.Unmarshal, nil
+	case "yaml", "yml":
+		return yaml.Unmarshal, nil
+	}
+	return nil, fmt.Errorf("unsupported format %q", format)
+}
+
+// Validate reports every invalid field of the configuration.
+func (c LayoutConfig) Validate() error {
+	var errs []error
+	nonNegative := func(name string, v float64) {
+		if v < 0 {
+			errs = append(errs, fmt.Errorf("%s must not be negative (got %.2f)", name, v))
+		}
+	}
+	positive := func(name string, v float64) {
+		if v <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive (got %.2f)", name, v))
+		}
+	}
+
+	nonNegative("margin_left", c.MarginLeft)
+	nonNegative("margin_right", c.MarginRight)
+	nonNegative("margin_top", c.MarginTop)
+	nonNegative("margin_bottom", c.MarginBottom)
+	nonNegative("inner_margin", c.InnerMargin)
+	nonNegative("outer_margin", c.OuterMargin)
+	positive("time_height", c.TimeHeight)
+	positive("font_size", c.FontSize)
+	positive("line_height", c.LineHeight)
+	nonNegative("entry_spacing", c.EntrySpacing)
+	nonNegative("element_spacing", c.ElementSpacing)
+	nonNegative("image_spacing", c.ImageSpacing)
+	positive("min_wide_height", c.MinWideHeight)
+	positive("min_tall_height", c.MinTallHeight)
+	nonNegative("single_image_height", c.SingleImageHeight)
+	nonNegative("single_image_width", c.SingleImageWidth)
+	positive("justified_row_height", c.JustifiedRowHeight)
+	nonNegative("keep_together.short_entry_height", c.KeepTogether.ShortEntryHeight)
+
+	for name, heights := range map[string][]float64{
+		"min_landscape_heights": c.MinLandscapeHeights,
+		"min_portrait_heights":  c.MinPortraitHeights,
+	} {
+		if len(heights) != maxTemplatePictures {
+			errs = append(errs, fmt.Errorf("%s must have %d entries (got %d)", name, maxTemplatePictures, len(heights)))
+			continue
+		}
+		for i, h := range heights {
+			positive(fmt.Sprintf("%s[%d]", name, i), h)
+		}
+	}
+
+	if c.ContinuationHeader && c.MarginTop < c.TimeHeight {
+		errs = append(errs, fmt.Errorf("continuation_header needs margin_top (%.2f) >= time_height (%.2f)", c.MarginTop, c.TimeHeight))
+	}
+	if vj := c.VerticalJustification; vj.Enabled {
+		if vj.MaxEntrySpacing < c.EntrySpacing {
+			errs = append(errs, fmt.Errorf("vertical_justification.max_entry_spacing (%.2f) must not be below entry_spacing (%.2f)", vj.MaxEntrySpacing, c.EntrySpacing))
+		}
+		if vj.MaxElementSpacing < c.ElementSpacing {
+			errs = append(errs, fmt.Errorf("vertical_justification.max_element_spacing (%.2f) must not be below element_spacing (%.2f)", vj.MaxElementSpacing, c.ElementSpacing))
+		}
+	}
+	if c.PaginationWindow < 0 {
+		errs = append(errs, fmt.Errorf("pagination_window must not be negative (got %d)", c.PaginationWindow))
+	}
+	if c.PictureLayout != PictureLayoutTemplates && c.PictureLayout != PictureLayoutJustified {
+		errs = append(errs, fmt.Errorf("picture_layout must be %q or %q (got %q)", PictureLayoutTemplates, PictureLayoutJustified, c.PictureLayout))
+	}
+	if c.PictureGroupSize < 3 || c.PictureGroupSize > maxTemplatePictures {
+		errs = append(errs, fmt.Errorf("picture_group_size must be between 3 and %d (got %d)", maxTemplatePictures, c.PictureGroupSize))
+	}
+	defined, err := c.definedTemplates()
+	if err != nil {
+		errs = append(errs, err)
+	}
+	definedNames := make(map[string]bool, len(defined))
+	for _, t := range defined {
+		definedNames[t.Name()] = true
+	}
+	for _, name := range c.DisabledTemplates {
+		if _, ok := lookupLayoutTemplate(name); !ok && !definedNames[name] {
+			errs = append(errs, fmt.Errorf("disabled_templates: unknown layout template %q", name))
+		}
+	}
+	if c.CropBudget < 0 || c.CropBudget >= 0.5 {
+		errs = append(errs, fmt.Errorf("crop_budget must be at least 0 and below 0.5 (got %.2f)", c.CropBudget))
+	}
+	if c.ImageDir != "" {
+		if info, err := os.Stat(c.ImageDir); err != nil || !info.IsDir() {
+			errs = append(errs, fmt.Errorf("image_dir %q is not a directory", c.ImageDir))
+		}
+	}
+	if _, err := c.layoutScorer(); err != nil {
+		errs = append(errs, err)
+	}
+	if c.Orphans < 1 {
+		errs = append(errs, fmt.Errorf("orphans must be at least 1 (got %d)", c.Orphans))
+	}
+	if c.Widows < 1 {
+		errs = append(errs, fmt.Errorf("widows must be at least 1 (got %d)", c.Widows))
+	}
+	if c.FontSize > c.LineHeight && c.LineHeight > 0 {
+		errs = append(errs, fmt.Errorf("font_size (%.2f) must not exceed line_height (%.2f)", c.FontSize, c.LineHeight))
+	}
+	positive("dpi", c.DPI)
+	positive("output_dpi", c.OutputDPI)
+	if c.Orientation != OrientationPortrait && c.Orientation != OrientationLandscape {
+		errs = append(errs, fmt.Errorf("orientation must be %q or %q (got %q)", OrientationPortrait, OrientationLandscape, c.Orientation))
+	}
+	if pageWidth, pageHeight, err := c.PageDimensions(); err != nil {
+		errs = append(errs, err)
+	} else if c.DPI > 0 {
+		// Margins are in reference pixels; compare in the same unit.
+		scale := c.dpiScale()
+		left, right := c.horizontalMargins(1)
+		if w := pageWidth/scale - left - right; w <= 0 {
+			errs = append(errs, fmt.Errorf("horizontal margins leave no printable width (%.2f)", w))
+		}
+		if h := pageHeight/scale - c.MarginTop - c.MarginBottom; h < c.TimeHeight {
+			errs = append(errs, fmt.Errorf("vertical margins leave %.2f printable height, less than time_height", h))
+		}
+	}
+
+	if c.FontFile != "" {
+		if _, err := cachedFontMeasurer(c.FontFile, c.FontIndex); err != nil {
+			errs = append(errs, fmt.Errorf("font_file: %w", err))
+		}
+	}
+
+	if path := c.LineBreaking.HyphenationPatterns; path != "" {
+		if _, err := cachedHyphenator(path); err != nil {
+			errs = append(errs, fmt.Errorf("line_breaking.hyphenation_patterns: %w", err))
+		}
+	}
+
+	if c.Emoticons.Enabled {
+		positive("emoticons.size", c.Emoticons.Size)
+		if c.Emoticons.Dir != "" {
+			if info, err := os.Stat(c.Emoticons.Dir); err != nil || !info.IsDir() {
+				errs = append(errs, fmt.Errorf("emoticons.dir %q is not a directory", c.Emoticons.Dir))
+			}
+		}
+	}
+
+	// Map iteration above is unordered; keep the report stable.
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+	return errors.Join(errs...)
+}
+
+// clone returns a copy that shares no slices with c.
+func (c LayoutConfig) clone() LayoutConfig {
+	c.MinLandscapeHeights = append([]float64(nil), c.MinLandscapeHeights...)
+	c.MinPortraitHeights = append([]float64(nil), c.MinPortraitHeights...)
+	c.DisabledTemplates = append([]string(nil), c.DisabledTemplates...)
+	if c.ScorerWeights != nil {
+		weights := make(map[string]float64, len(c.ScorerWeights))
+		for name, weight := range c.ScorerWeights {
+			weights[name] = weight
+		}
+		c.ScorerWeights = weights
+	}
+	if c.Emoticons.Codes != nil {
+		codes := make(map[string]string, len(c.Emoticons.Codes))
+		for code, file := range c.Emoticons.Codes {
+			codes[code] = file
+		}
+		c.Emoticons.Codes = codes
+	}
+	return c
+}
@@ -0,0 +1,141 @@
+package waterfall
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLayoutConfigPresetsValidate(t *testing.T) {
+	names := LayoutConfigPresets()
+	if want := []string{"compact", "default", "spacious"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("LayoutConfigPresets() = %v, want %v", names, want)
+	}
+	for _, name := range names {
+		cfg, err := LayoutConfigPreset(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("preset %s is invalid: %v", name, err)
+		}
+	}
+
+	// Presets return copies the caller may modify.
+	cfg, _ := LayoutConfigPreset("compact")
+	cfg.MinLandscapeHeights[0] = 1
+	if again, _ := LayoutConfigPreset(" Compact "); again.MinLandscapeHeights[0] == 1 {
+		t.Error("modifying a preset changed the next one")
+	}
+	if _, err := LayoutConfigPreset("huge"); err == nil || !strings.Contains(err.Error(), "compact, default, spacious") {
+		t.Errorf("unknown preset error %v, want the available presets listed", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *LayoutConfig)
+		want   []string // parts of the error, nil for a valid config
+	}{
+		{"default", func(c *LayoutConfig) {}, nil},
+		{"negative margin", func(c *LayoutConfig) { c.MarginLeft = -1 }, []string{"margin_left must not be negative"}},
+		{"zero font size", func(c *LayoutConfig) { c.FontSize = 0 }, []string{"font_size must be positive"}},
+		{"short height table", func(c *LayoutConfig) { c.MinPortraitHeights = []float64{800} }, []string{"min_portrait_heights must have 9 entries"}},
+		{"zero height", func(c *LayoutConfig) { c.MinLandscapeHeights[2] = 0 }, []string{"min_landscape_heights[2] must be positive"}},
+		{"font above the line", func(c *LayoutConfig) { c.FontSize = c.LineHeight + 1 }, []string{"must not exceed line_height"}},
+		{"margins fill the page", func(c *LayoutConfig) { c.MarginLeft, c.MarginRight = 2000, 2000 }, []string{"no printable width"}},
+		{"continuation header without room", func(c *LayoutConfig) {
+			c.ContinuationHeader = true
+			c.MarginTop = c.TimeHeight - 1
+		}, []string{"continuation_header needs margin_top"}},
+		{"every error reported", func(c *LayoutConfig) {
+			c.EntrySpacing = -1
+			c.Orphans = 0
+			c.PictureLayout = "mosaic"
+		}, []string{"entry_spacing must not be negative", "orphans must be at least 1", "picture_layout must be"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultLayoutConfig()
+			tt.modify(&cfg)
+			err := cfg.Validate()
+			if tt.want == nil {
+				if err != nil {
+					t.Errorf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Validate() = nil, want %q", tt.want)
+			}
+			for _, part := range tt.want {
+				if !strings.Contains(err.Error(), part) {
+					t.Errorf("Validate() = %v, want it to contain %q", err, part)
+				}
+			}
+		})
+	}
+}
+
+func TestParseLayoutConfig(t *testing.T) {
+	yamlDoc := []byte("preset: compact\nentry_spacing: 120\nmin_wide_height: 500\n")
+	cfg, err := ParseLayoutConfig(yamlDoc, "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	compact, _ := LayoutConfigPreset("compact")
+	if cfg.EntrySpacing != 120 || cfg.MinWideHeight != 500 || cfg.MarginTop != compact.MarginTop || cfg.Preset != "compact" {
+		t.Errorf("parsed config has entry_spacing %.0f, min_wide_height %.0f, margin_top %.0f and preset %q; want the document on top of the compact preset",
+			cfg.EntrySpacing, cfg.MinWideHeight, cfg.MarginTop, cfg.Preset)
+	}
+
+	cfg, err = ParseLayoutConfig([]byte(`{"font_size": 60}`), "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := DefaultLayoutConfig(); cfg.FontSize != 60 || cfg.LineHeight != want.LineHeight {
+		t.Errorf("parsed JSON config has font_size %.0f and line_height %.0f, want 60 on top of the defaults", cfg.FontSize, cfg.LineHeight)
+	}
+
+	for _, bad := range []struct {
+		doc, format string
+	}{
+		{`{"font_size": -1}`, "json"},  // invalid
+		{`{"preset": "huge"}`, "json"}, // unknown preset
+		{`{"font_size": `, "json"},     // malformed
+		{"font_size: 60\n", "toml"},    // unsupported format
+	} {
+		if _, err := ParseLayoutConfig([]byte(bad.doc), bad.format); err == nil {
+			t.Errorf("ParseLayoutConfig(%q, %q) accepted", bad.doc, bad.format)
+		}
+	}
+}
+
+func TestLoadLayoutConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "book.yml")
+	if err := os.WriteFile(path, []byte("preset: spacious\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadLayoutConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spacious, _ := LayoutConfigPreset("spacious"); cfg.EntrySpacing != spacious.EntrySpacing {
+		t.Errorf("loaded entry_spacing %.0f, want the spacious preset's %.0f", cfg.EntrySpacing, spacious.EntrySpacing)
+	}
+	if _, err := LoadLayoutConfig(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("missing config file accepted")
+	}
+}
+
+func TestLayoutRejectsInvalidConfig(t *testing.T) {
+	cfg := DefaultLayoutConfig()
+	cfg.LineHeight = 0
+	if _, err := Layout([]Entry{{ID: 1, Time: "2025-03-30 17:50:00", Text: "好"}}, cfg); err == nil {
+		t.Error("Layout accepted an invalid config")
+	}
+}
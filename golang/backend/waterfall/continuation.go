@@ -0,0 +1,111 @@
+package waterfall
+
+import (
+	"fmt"
+	"time"
+)
+
+// continuationMark is appended to the date of a continuation header.
+const continuationMark = "（续）"
+
+// parseEntryTime parses an entry time in one of the formats of the entry data.
+func parseEntryTime(timeStr string) (time.Time, bool) {
+	layouts := []string{
+		"2006-01-02 15:04:05",
+		"2006年1月2日 15:04",
+		"2006年01月02日 15:04",
+	}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, timeStr); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// formatEntryTime splits an entry time into the date part ("5月23日 周一") and the
+// time part ("08:28"). Both are empty when the time cannot be parsed.
+func formatEntryTime(timeStr string) (datePart, timePart string) {
+	t, ok := parseEntryTime(timeStr)
+	if !ok {
+		return "", ""
+	}
+	weekdayMap := map[time.Weekday]string{
+		time.Sunday: "周日", time.Monday: "周一", time.Tuesday: "周二",
+		time.Wednesday: "周三", time.Thursday: "周四", time.Friday: "周五",
+		time.Saturday: "周六",
+	}
+	datePart = fmt.Sprintf("%d月%d日 %s", t.Month(), t.Day(), weekdayMap[t.Weekday()])
+	timePart = fmt.Sprintf("%02d:%02d", t.Hour(), t.Minute())
+	return datePart, timePart
+}
+
+// markEntryContinued flags the active entry's fragment on the current page as
+// continuing on the next page. A continuation fragment that received no content is
+// dropped instead; the fragment before it keeps its flag.
+func (e *ContinuousLayoutEngine) markEntryContinued() {
+	if e.currentPage == nil || len(e.currentPage.Entries) == 0 {
+		return
+	}
+	last := len(e.currentPage.Entries) - 1
+	fragment := &e.currentPage.Entries[last]
+	if fragment.ID != e.activeEntry.ID {
+		return
+	}
+	if fragment.IsContinuation && isEmptyFragment(fragment) {
+		e.currentPage.Entries = e.currentPage.Entries[:last]
+		return
+	}
+	fragment.ContinuesOnNextPage = true
+}
+
+// addContinuationFragment starts the active entry's fragment on a new page. With
+// ContinuationHeader set it carries a "（续）" date header placed in the top margin, so
+// the page's content area is unchanged.
+func (e *ContinuousLayoutEngine) addContinuationFragment() {
+	entry := e.activeEntry
+	fragment := PageEntry{
+		ID:             entry.ID,
+		Time:           entry.Time,
+		TextAreas:      make([][][]float64, 0),
+		Texts:          make([]string, 0),
+		Pictures:       make([]Picture, 0),
+		IsContinuation: true,
+	}
+	fragment.DatePart, fragment.TimePart = formatEntryTime(entry.Time)
+	if e.config.ContinuationHeader {
+		fragment.DatePart += continuationMark
+		fragment.TimeArea = [][]float64{
+			{e.marginLeft, e.marginTop - e.timeHeight},
+			{e.marginLeft + e.availableWidth, e.marginTop},
+		}
+	}
+	e.currentPage.Entries = append(e.currentPage.Entries, fragment)
+}
+
+// finishEntry ends the active entry. A trailing continuation fragment that received
+// no content is removed and the previous fragment no longer continues.
+func (e *ContinuousLayoutEngine) finishEntry() {
+	defer func() { e.activeEntry = nil }()
+	if len(e.currentPage.Entries) == 0 {
+		return
+	}
+	last := len(e.currentPage.Entries) - 1
+	fragment := &e.currentPage.Entries[last]
+	if !fragment.IsContinuation || fragment.ID != e.activeEntry.ID || !isEmptyFragment(fragment) {
+		return
+	}
+	e.currentPage.Entries = e.currentPage.Entries[:last]
+	for p := len(e.pages) - 2; p >= 0; p-- {
+		if entries := e.pages[p].Entries; len(entries) > 0 {
+			if previous := &entries[len(entries)-1]; previous.ID == e.activeEntry.ID {
+				previous.ContinuesOnNextPage = false
+			}
+			break
+		}
+	}
+}
+
+func isEmptyFragment(fragment *PageEntry) bool {
+	return len(fragment.TextAreas) == 0 && len(fragment.Pictures) == 0
+}
@@ -0,0 +1,76 @@
+package waterfall
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatEntryTime(t *testing.T) {
+	tests := []struct {
+		time, date, clock string
+	}{
+		{"2025-03-30 17:50:00", "3月30日 周日", "17:50"},
+		{"2025年3月3日 08:05", "3月3日 周一", "08:05"},
+		{"2025年03月03日 08:05", "3月3日 周一", "08:05"},
+		{"yesterday", "", ""},
+	}
+	for _, tt := range tests {
+		if date, clock := formatEntryTime(tt.time); date != tt.date || clock != tt.clock {
+			t.Errorf("formatEntryTime(%q) = %q, %q, want %q, %q", tt.time, date, clock, tt.date, tt.clock)
+		}
+	}
+}
+
+func TestContinuationFlags(t *testing.T) {
+	long := Entry{ID: 1, Time: "2025-03-30 17:50:00", Text: strings.Repeat("今天天气很好，我们去公园散步。\n", 120)}
+	pictures := Entry{ID: 2, Time: "2025-03-30 18:00:00", Pictures: fallbackPictures(20, 0, 0)}
+	short := Entry{ID: 3, Time: "2025-03-30 19:00:00", Text: "短的"}
+
+	for _, header := range []bool{false, true} {
+		cfg := DefaultLayoutConfig()
+		cfg.ContinuationHeader = header
+		e := NewContinuousLayoutEngineWithConfig([]Entry{long, pictures, short}, cfg)
+		e.logger = discardLogger
+		pages, err := e.ProcessEntries()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fragments := make(map[int64][]PageEntry)
+		var order []int64
+		for _, page := range pages {
+			for _, entry := range page.Entries {
+				if len(fragments[entry.ID]) == 0 {
+					order = append(order, entry.ID)
+				}
+				fragments[entry.ID] = append(fragments[entry.ID], entry)
+			}
+		}
+		if len(order) != 3 || order[0] != 1 || order[1] != 2 || order[2] != 3 {
+			t.Fatalf("header %v: fragments of entries %v, want 1, 2 and 3 in order", header, order)
+		}
+		if len(fragments[1]) < 3 || len(fragments[2]) < 2 || len(fragments[3]) != 1 {
+			t.Fatalf("header %v: entries split into %d, %d and %d fragments", header, len(fragments[1]), len(fragments[2]), len(fragments[3]))
+		}
+
+		for _, entry := range []Entry{long, pictures, short} {
+			parts := fragments[entry.ID]
+			for i, part := range parts {
+				first, last := i == 0, i == len(parts)-1
+				if part.Time != entry.Time || part.IsContinuation == first || part.ContinuesOnNextPage == last {
+					t.Errorf("header %v: entry %d fragment %d has time %q, is_continuation %v, continues_on_next_page %v",
+						header, entry.ID, i, part.Time, part.IsContinuation, part.ContinuesOnNextPage)
+				}
+				if first {
+					continue
+				}
+				if got := strings.HasSuffix(part.DatePart, continuationMark); got != header {
+					t.Errorf("header %v: entry %d fragment %d has date %q", header, entry.ID, i, part.DatePart)
+				}
+				if header && (len(part.TimeArea) != 2 || part.TimeArea[1][1] > e.marginTop) {
+					t.Errorf("header %v: entry %d fragment %d has its header at %v, not in the top margin", header, entry.ID, i, part.TimeArea)
+				}
+			}
+		}
+	}
+}
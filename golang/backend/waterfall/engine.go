@@ -6,36 +6,101 @@ import (
 	"math"
 	"strings"
 	"time"
-	"unicode/utf8"
 )
 
-// NewContinuousLayoutEngine creates a new continuous layout engine
-// UPDATED TO INITIALIZE NEW FIELDS
+// NewContinuousLayoutEngine creates a new continuous layout engine with the default configuration.
 func NewContinuousLayoutEngine(entries []Entry) *ContinuousLayoutEngine {
+	return NewContinuousLayoutEngineWithConfig(entries, DefaultLayoutConfig())
+}
+
+// NewContinuousLayoutEngineWithConfig creates a continuous layout engine using cfg.
+// The config is not validated here; use cfg.Validate or Layout for that.
+// Config lengths are converted from ReferenceDPI to the configured DPI.
+func NewContinuousLayoutEngineWithConfig(entries []Entry, cfg LayoutConfig) *ContinuousLayoutEngine {
+	cfg = cfg.clone()
+	scale := cfg.dpiScale()
+	scaleAll := func(values []float64) []float64 {
+		scaled := make([]float64, len(values))
+		for i, v := range values {
+			scaled[i] = v * scale
+		}
+		return scaled
+	}
+
 	engine := &ContinuousLayoutEngine{
-		entries:        entries,
-		marginLeft:     142,
-		marginRight:    142,
-		marginTop:      189,
-		marginBottom:   189,
-		timeHeight:     100,
-		fontSize:       66.67, // 对应72DPI的16px
-		lineHeight:     100,   // 对应72DPI的24px
-		entrySpacing:   150,   // 条目之间的间距
-		elementSpacing: 30,    // 元素整体之间的间距
-		imageSpacing:   15,    // 图片之间的间距
-		minWideHeight:  600,   // Min height for Wide pics (AR >= 3)
-		minTallHeight:  800,   // Min height for Tall pics (AR <= 1/3)
-
-		// Added slices for 1-9 pictures (index 0 unused)
-		minLandscapeHeights: []float64{600, 600, 400, 600, 600, 600, 600, 600, 600}, // 横图 1-9 张
-		minPortraitHeights:  []float64{800, 800, 600, 800, 800, 800, 800, 800, 800}, // 竖图 1-9 张
-
-		singleImageHeight: 3130, // 设置单张竖图的最大高度
-		singleImageWidth:  2124, // 设置单张横图的最大度
-	}
-	engine.availableWidth = 2480 - engine.marginLeft - engine.marginRight
-	engine.availableHeight = 3508 - engine.marginTop - engine.marginBottom
+		entries:         entries,
+		config:          cfg,
+		dpiScale:        scale,
+		firstPageNumber: 1,
+		marginTop:       cfg.MarginTop * scale,
+		marginBottom:    cfg.MarginBottom * scale,
+		timeHeight:      cfg.TimeHeight * scale,
+		fontSize:        cfg.FontSize * scale,
+		lineHeight:      cfg.LineHeight * scale,
+		entrySpacing:    cfg.EntrySpacing * scale,
+		elementSpacing:  cfg.ElementSpacing * scale,
+		imageSpacing:    cfg.ImageSpacing * scale,
+		minWideHeight:   cfg.MinWideHeight * scale,
+		minTallHeight:   cfg.MinTallHeight * scale,
+
+		minLandscapeHeights: scaleAll(cfg.MinLandscapeHeights),
+		minPortraitHeights:  scaleAll(cfg.MinPortraitHeights),
+
+		singleImageHeight: cfg.SingleImageHeight * scale,
+		singleImageWidth:  cfg.SingleImageWidth * scale,
+
+		justifiedRowHeight: cfg.JustifiedRowHeight * scale,
+	}
+
+	measurer, err := cfg.textMeasurer()
+	if err != nil {
+		// Unreadable fonts are rejected by Validate; measure with fixed widths otherwise.
+		measurer = FixedWidthMeasurer{}
+	}
+	engine.measurer = measurer
+	engine.lineBreaker = newLineBreaker(cfg.LineBreaking)
+	engine.inline = newInlineImages(cfg.Emoticons)
+	if templates, err := cfg.definedTemplates(); err == nil {
+		// Invalid template files are rejected by Validate; use the registered templates only otherwise.
+		engine.templates = templates
+	}
+	scorer, err := cfg.layoutScorer()
+	if err != nil {
+		// Unknown scorers are rejected by Validate; rank by area otherwise.
+		scorer = builtinScorers[ScorerArea]
+	}
+	engine.scorer = scorer
+	if cfg.CropBudget > 0 && cfg.CropBudget < 0.5 {
+		// Out-of-range budgets are rejected by Validate; do not crop otherwise.
+		engine.cropBudget = cfg.CropBudget
+	}
+	if cfg.ImageDir != "" {
+		engine.imageLoader = loadImageFromDir(cfg.ImageDir)
+	}
+	engine.logger = packageLogger()
+	if cfg.Trace {
+		engine.trace = &LayoutTrace{}
+	}
+	engine.disabledTemplates = make(map[string]bool, len(cfg.DisabledTemplates))
+	for _, name := range cfg.DisabledTemplates {
+		engine.disabledTemplates[name] = true
+	}
+	if path := cfg.LineBreaking.HyphenationPatterns; path != "" {
+		// Unreadable pattern files are rejected by Validate; wrap without hyphens otherwise.
+		if hyphenator, err := cachedHyphenator(path); err == nil {
+			engine.lineBreaker.hyphenator = hyphenator
+		}
+	}
+
+	pageWidth, pageHeight, err := cfg.PageDimensions()
+	if err != nil {
+		// Invalid page sizes are rejected by Validate; fall back to A4 for unvalidated configs.
+		pageWidth, pageHeight = pageSizeCatalog["a4"].Pixels(cfg.DPI, cfg.Orientation)
+	}
+	engine.pageWidth = pageWidth
+	engine.pageHeight = pageHeight
+	engine.applyPageMargins(engine.firstPageNumber)
+	engine.availableHeight = pageHeight - engine.marginTop - engine.marginBottom
 
 	// 从第一个条目中获取年月信息
 	if len(entries) > 0 {
@@ -48,19 +113,87 @@ func NewContinuousLayoutEngine(entries []Entry) *ContinuousLayoutEngine {
 	return engine
 }
 
-// ProcessEntries processes all entries and returns the layout result
+// SetFirstPageNumber sets the number given to the first page laid out (1 by default).
+// In spread mode its parity decides which side the first page is on.
+func (e *ContinuousLayoutEngine) SetFirstPageNumber(page int) {
+	e.firstPageNumber = page
+	e.applyPageMargins(page)
+}
+
+// SetLayoutScorer replaces the scorer selected by the config's scorer field.
+func (e *ContinuousLayoutEngine) SetLayoutScorer(s LayoutScorer) {
+	e.scorer = s
+}
+
+// SetImageLoader replaces the loader reading pictures from the config's image_dir for
+// focal point detection. A nil loader switches detection off.
+func (e *ContinuousLayoutEngine) SetImageLoader(l ImageLoader) {
+	e.imageLoader = l
+	e.focusCache = nil
+}
+
+// SetTextMeasurer replaces the measurer used for line breaking.
+func (e *ContinuousLayoutEngine) SetTextMeasurer(m TextMeasurer) {
+	e.measurer = m
+}
+
+// applyPageMargins sets the horizontal margins for the given page number. The inner
+// and outer margins only swap sides, so availableWidth stays the same on every page.
+func (e *ContinuousLayoutEngine) applyPageMargins(page int) {
+	left, right := e.config.horizontalMargins(page)
+	e.marginLeft = left * e.dpiScale
+	e.marginRight = right * e.dpiScale
+	e.availableWidth = e.pageWidth - e.marginLeft - e.marginRight
+}
+
+// ProcessEntries processes all entries and returns the layout result. In strict mode
+// it fails with a *LayoutError after the first entry that could not be laid out as
+// configured.
 func (e *ContinuousLayoutEngine) ProcessEntries() ([]ContinuousLayoutPage, error) {
+	var breaks map[int]bool
+	var choices []pictureChoice
+	if e.config.OptimizePagination && len(e.entries) > 1 {
+		breaks, choices = e.optimizePageBreaks()
+	}
+
 	e.newPage() // Start with a fresh page
 
-	for _, entry := range e.entries {
+	for i, entry := range e.entries {
+		e.traceEntry(entry)
+		if breaks[i] && e.trace != nil {
+			e.trace.PageBreaks = append(e.trace.PageBreaks, entry.ID)
+		}
+		if breaks[i] && e.currentY > e.marginTop {
+			e.traceRule("Pagination", "optimize_pagination", "The pagination optimizer starts the entry on a new page.")
+			e.newPage()
+		}
+		if choices != nil {
+			e.pictureChoice = choices[i]
+			switch e.pictureChoice {
+			case pictureChoiceFullScale:
+				e.traceRule("Pagination", "optimize_pagination", "The pagination optimizer keeps the pictures at full scale below the top of a page.")
+			case pictureChoiceNewPage:
+				e.traceRule("Pagination", "optimize_pagination", "The pagination optimizer keeps the pictures together on one page.")
+			}
+		}
 		// Let processEntry handle content placement and pagination internally
 		e.processEntry(entry, entry.ID)
+		if e.strictErr != nil {
+			return nil, e.strictErr
+		}
 	}
 
+	if e.config.VerticalJustification.Enabled {
+		e.justifyPages()
+	}
 	return e.pages, nil
 }
 
 func (e *ContinuousLayoutEngine) processEntry(entry Entry, entryID int64) {
+	if e.entryStartsNewPage(entry) {
+		e.newPage()
+	}
+
 	// Add entry spacing if this isn't the first element on the page
 	// We need a more robust check than just e.currentY > e.marginTop
 	// Check if the current page actually has content already placed
@@ -76,6 +209,11 @@ func (e *ContinuousLayoutEngine) processEntry(entry Entry, entryID int64) {
 		}
 	}
 
+	// Pages started while the entry is active get a continuation fragment
+	e.activeEntry = &entry
+	e.activeEntryStarted = false
+	defer e.finishEntry()
+
 	// 1. Process Time
 	e.addTime(entry.Time, entryID)
 
@@ -124,33 +262,11 @@ func (e *ContinuousLayoutEngine) addTime(timeStr string, entryID int64) {
 		Pictures:  make([]Picture, 0),
 	}
 
-	// Parse and format time if possible
-	layouts := []string{
-		"2006-01-02 15:04:05",
-		"2006年1月2日 15:04",
-		"2006年01月02日 15:04",
-	}
-	var t time.Time
-	var err error
-	for _, layout := range layouts {
-		t, err = time.Parse(layout, timeStr)
-		if err == nil {
-			break
-		}
-	}
-
-	if err == nil {
-		weekdayMap := map[time.Weekday]string{
-			time.Sunday: "周日", time.Monday: "周一", time.Tuesday: "周二",
-			time.Wednesday: "周三", time.Thursday: "周四", time.Friday: "周五",
-			time.Saturday: "周六",
-		}
-		pageEntry.DatePart = fmt.Sprintf("%d月%d日 %s", t.Month(), t.Day(), weekdayMap[t.Weekday()])
-		pageEntry.TimePart = fmt.Sprintf("%02d:%02d", t.Hour(), t.Minute())
-	}
+	pageEntry.DatePart, pageEntry.TimePart = formatEntryTime(timeStr)
 
 	// Add the newly created entry to the current page
 	e.currentPage.Entries = append(e.currentPage.Entries, pageEntry)
+	e.activeEntryStarted = true
 
 	// Update current Y position *after* placing time
 	// Add elementSpacing only if text or pictures will follow
@@ -185,22 +301,13 @@ func (e *ContinuousLayoutEngine) addTextChunk(chunk []string) {
 
 	// Add element spacing *before* the text if needed
 	// Check if the entry currently only contains the TimeArea
-	if len(currentEntry.TextAreas) == 0 && len(currentEntry.Pictures) == 0 && len(currentEntry.TimeArea) > 0 {
+	if len(currentEntry.TextAreas) == 0 && len(currentEntry.Pictures) == 0 && len(currentEntry.TimeArea) > 0 && !currentEntry.IsContinuation {
 		// Check for space before adding spacing + text
 		pageAvailableHeight := e.availableHeight - (e.currentY - e.marginTop)
 		if pageAvailableHeight < e.elementSpacing+e.lineHeight {
 			// Not enough space for spacing + 1 line of text, force new page
 			e.newPage()
-			// Create a new entry on the new page to hold the continued text
-			// (or handle splitting the original entry across pages - more complex)
-			// Simpler: Assume the text chunk continues in a new logical block.
-			entry := PageEntry{
-				// Copy relevant identifiers? No, treat as new block for now.
-				TextAreas: make([][][]float64, 0),
-				Texts:     make([]string, 0),
-				Pictures:  make([]Picture, 0),
-			}
-			e.currentPage.Entries = append(e.currentPage.Entries, entry)
+			// newPage starts a continuation fragment of the entry to hold the text
 			currentEntryIndex = len(e.currentPage.Entries) - 1 // Update index
 			currentEntry = &e.currentPage.Entries[currentEntryIndex]
 			// No spacing needed at start of new page/entry
@@ -218,7 +325,13 @@ func (e *ContinuousLayoutEngine) addTextChunk(chunk []string) {
 	}
 
 	currentEntry.TextAreas = append(currentEntry.TextAreas, area)
-	currentEntry.Texts = append(currentEntry.Texts, strings.Join(chunk, "\n"))
+	currentEntry.Texts = append(currentEntry.Texts, e.inline.expand(strings.Join(chunk, "\n")))
+	if e.config.LineBreaking.PunctuationCompression {
+		currentEntry.CompressedPunctuation = append(currentEntry.CompressedPunctuation, e.compressedPunctuation(chunk))
+	}
+	if e.inline != nil {
+		currentEntry.InlineRuns = append(currentEntry.InlineRuns, e.inlineRuns(chunk, startY)...)
+	}
 
 	// Update Y position after adding text chunk
 	e.currentY += textHeight
@@ -230,24 +343,7 @@ func (e *ContinuousLayoutEngine) processText(text string) {
 		return
 	}
 
-	charsPerLine := int(e.availableWidth / e.fontSize)
-	var lines []string
-	for _, paragraph := range strings.Split(text, "\n") {
-		charCount := utf8.RuneCountInString(paragraph)
-		if charCount <= charsPerLine {
-			lines = append(lines, paragraph)
-		} else {
-			runes := []rune(paragraph)
-			for i := 0; i < len(runes); i += charsPerLine {
-				end := i + charsPerLine
-				if end > len(runes) {
-					end = len(runes)
-				}
-				line := string(runes[i:end])
-				lines = append(lines, line)
-			}
-		}
-	}
+	lines, paragraphs := e.wrapText(e.inline.substitute(text))
 
 	currentLine := 0
 	for currentLine < len(lines) {
@@ -259,21 +355,14 @@ func (e *ContinuousLayoutEngine) processText(text string) {
 		// Check if spacing needs to be added *before* this text block
 		if currentLine == 0 && len(e.currentPage.Entries) > 0 {
 			lastEntry := &e.currentPage.Entries[len(e.currentPage.Entries)-1]
-			if len(lastEntry.TextAreas) == 0 && len(lastEntry.Pictures) == 0 && len(lastEntry.TimeArea) > 0 {
+			if len(lastEntry.TextAreas) == 0 && len(lastEntry.Pictures) == 0 && len(lastEntry.TimeArea) > 0 && !lastEntry.IsContinuation {
 				requiredHeight += e.elementSpacing
 			}
 		}
 
 		if pageAvailableHeight < requiredHeight {
 			// Not enough space even for one more line (+ potentially spacing)
-			e.newPage()
-			// Create a new entry on the new page
-			entry := PageEntry{
-				TextAreas: make([][][]float64, 0),
-				Texts:     make([]string, 0),
-				Pictures:  make([]Picture, 0),
-			}
-			e.currentPage.Entries = append(e.currentPage.Entries, entry)
+			e.newPage() // Starts a continuation fragment of the entry
 			// Recalculate available height for the loop check
 			pageAvailableHeight = e.availableHeight // available on new page
 			// Continue loop to place the line on the new page
@@ -284,7 +373,7 @@ func (e *ContinuousLayoutEngine) processText(text string) {
 		firstLineSpacing := 0.0
 		if len(e.currentPage.Entries) > 0 {
 			lastEntry := &e.currentPage.Entries[len(e.currentPage.Entries)-1]
-			if len(lastEntry.TextAreas) == 0 && len(lastEntry.Pictures) == 0 && len(lastEntry.TimeArea) > 0 {
+			if len(lastEntry.TextAreas) == 0 && len(lastEntry.Pictures) == 0 && len(lastEntry.TimeArea) > 0 && !lastEntry.IsContinuation {
 				firstLineSpacing = e.elementSpacing
 			}
 		}
@@ -303,13 +392,17 @@ func (e *ContinuousLayoutEngine) processText(text string) {
 				// This state implies a new page was needed but logic failed? Log error maybe.
 				// Force a page break to avoid infinite loop
 				e.newPage()
-				entry := PageEntry{}
-				e.currentPage.Entries = append(e.currentPage.Entries, entry)
 				continue // Retry placement on new page
 			}
 		}
 
 		numLinesToAdd := int(math.Min(float64(len(lines)-currentLine), float64(availableLines)))
+		numLinesToAdd = e.keepLines(paragraphs, currentLine, numLinesToAdd, e.currentY == e.marginTop)
+		if numLinesToAdd == 0 {
+			// Widow/orphan rules move the rest of the paragraph to the next page.
+			e.newPage()
+			continue
+		}
 		chunk := lines[currentLine : currentLine+numLinesToAdd]
 		e.addTextChunk(chunk) // addTextChunk now handles spacing and page break check before adding
 		currentLine += numLinesToAdd
@@ -320,14 +413,31 @@ func (e *ContinuousLayoutEngine) processText(text string) {
 }
 
 func (e *ContinuousLayoutEngine) newPage() {
+	continuing := e.activeEntry != nil && e.activeEntryStarted
+	if continuing {
+		e.markEntryContinued()
+	}
+
+	if e.trace != nil && e.currentPage != nil {
+		empty := e.marginTop + e.availableHeight - e.currentY
+		e.traceDecision(TraceDecision{Kind: TracePageBreak, Detail: fmt.Sprintf("%.0f px left at the bottom of page %d", math.Max(0, empty), e.currentPage.Page)})
+	}
+
+	number := e.firstPageNumber + len(e.pages)
 	page := &ContinuousLayoutPage{
-		Page:    len(e.pages) + 1,
+		Page:    number,
+		Side:    PageSide(number),
 		Entries: make([]PageEntry, 0),
 	}
+	e.applyPageMargins(number)
 	e.pages = append(e.pages, *page)
 	e.currentPage = &e.pages[len(e.pages)-1]
 	e.currentY = e.marginTop
 	e.timeAreaBottom = 0
+
+	if continuing {
+		e.addContinuationFragment()
+	}
 }
 
 // processPictures handles layout and pagination for a block of pictures.
@@ -337,6 +447,24 @@ func (e *ContinuousLayoutEngine) processPictures(pictures []Picture) {
 		return
 	}
 
+	e.checkPictureDimensions(pictures)
+
+	// Whatever the strategies below fail to place is placed by the last-resort path,
+	// which reports cause as the reason in strict mode.
+	var cause error
+	mark := e.markPictures()
+	defer func() { e.placeMissingPictures(pictures, mark, cause) }()
+
+	if e.picturesStartNewPage(pictures) {
+		e.newPage()
+	}
+
+	if e.config.PictureLayout == PictureLayoutJustified && numPicsTotal > 1 {
+		e.traceRule("processPictures", "Justified rows", "picture_layout is justified.")
+		e.processJustifiedPictures(pictures)
+		return
+	}
+
 	// --- Check for Ultra-Wide or Ultra-Tall Pictures ---
 	hasUltraWideOrTall := false
 	ultraThreshold := 4.0
@@ -349,7 +477,7 @@ func (e *ContinuousLayoutEngine) processPictures(pictures []Picture) {
 				if ar <= (1.0 / ultraThreshold) {
 					picType = "ultra-tall"
 				}
-				fmt.Printf("Debug (ProcessPics): %s picture detected (Index %d, AR %.2f). Will use dynamic layout.\n", picType, pic.Index, ar)
+				e.debugf("ProcessPics", "%s picture detected (Index %d, AR %.2f). Will use dynamic layout.", picType, pic.Index, ar)
 				break
 			}
 		}
@@ -358,7 +486,8 @@ func (e *ContinuousLayoutEngine) processPictures(pictures []Picture) {
 	// --- Choose Layout Strategy ---
 	if hasUltraWideOrTall {
 		// +++ Use NEW Dynamic Row-by-Row Strategy +++
-		fmt.Println("Debug (ProcessPics): Using dynamic row layout strategy.")
+		e.debugf("ProcessPics", "Using dynamic row layout strategy.")
+		e.traceRule("processPictures", "Dynamic rows", "An ultra-wide or ultra-tall picture is laid out row by row with the others.")
 
 		currentIndex := 0
 		for currentIndex < numPicsTotal {
@@ -366,7 +495,7 @@ func (e *ContinuousLayoutEngine) processPictures(pictures []Picture) {
 			requiredSpacing := e.requiredSpacingBeforeElement()
 
 			// 2. Estimate minimum height for the *next potential row* (Simple estimate)
-			estimatedMinHeight := 100.0 // Baseline estimate
+			estimatedMinHeight := 100.0 * e.dpiScale // Baseline estimate
 			if currentIndex < numPicsTotal {
 				pic := pictures[currentIndex]
 				ar := 1.0
@@ -396,7 +525,7 @@ func (e *ContinuousLayoutEngine) processPictures(pictures []Picture) {
 			// 5. Calculate Available Height for Row Placement
 			layoutAvailableHeight := (e.marginTop + e.availableHeight) - e.currentY
 			if layoutAvailableHeight <= 1e-6 { // Use tolerance
-				fmt.Printf("Warning: No available height left (%.2f) on page %d for picture row starting at index %d. Skipping remaining pictures.\n", layoutAvailableHeight, e.currentPage.Page, currentIndex)
+				e.warnf("processPictures", "No available height left (%.2f) on page %d for picture row starting at index %d. Deferring the remaining pictures to the fallback path.", layoutAvailableHeight, e.currentPage.Page, currentIndex)
 				break
 			}
 
@@ -405,35 +534,35 @@ func (e *ContinuousLayoutEngine) processPictures(pictures []Picture) {
 			numPicturesConsumed := len(picsInNextRow)
 
 			if numPicturesConsumed == 0 {
-				fmt.Printf("Warning: Could not determine layout for picture index %d. Skipping remaining pictures.\n", currentIndex)
+				e.warnf("processPictures", "Could not determine layout for picture index %d. Deferring the remaining pictures to the fallback path.", currentIndex)
 				break
 			}
 
 			// 7. Calculate the actual layout for this specific row
 			rowLayoutInfo, err := e.calculateRowLayout(picsInNextRow, rowConfigType, layoutAvailableHeight)
 			if err != nil {
-				fmt.Printf("Error calculating row layout for %d pictures (type: %s) starting at index %d: %v. Skipping row.\n", numPicturesConsumed, rowConfigType, currentIndex, err)
-				// Simple strategy: skip the problematic picture(s) and try next
-				currentIndex += numPicturesConsumed
-				continue // Try the next iteration
+				e.errorf("processPictures", "Failed to calculate row layout for %d pictures (type: %s) starting at index %d: %v. Deferring the remaining pictures to the fallback path.", numPicturesConsumed, rowConfigType, currentIndex, err)
+				cause = fmt.Errorf("row at picture %d: %w", picsInNextRow[0].Index, err)
+				// Placing the next rows first would print them before this one; the fallback keeps the order.
+				break
 			}
 
 			// 8. Double check if calculated height fits (should be handled by calculateRowLayout ideally)
 			if rowLayoutInfo.TotalHeight > layoutAvailableHeight+1e-6 {
-				fmt.Printf("Error: Calculated row height (%.2f) exceeds available height (%.2f) for %d pics (type: %s) starting at index %d. Skipping row.\n", rowLayoutInfo.TotalHeight, layoutAvailableHeight, numPicturesConsumed, rowConfigType, currentIndex)
-				currentIndex += numPicturesConsumed
-				continue
+				e.errorf("processPictures", "Calculated row height (%.2f) exceeds available height (%.2f) for %d pics (type: %s) starting at index %d. Deferring the remaining pictures to the fallback path.", rowLayoutInfo.TotalHeight, layoutAvailableHeight, numPicturesConsumed, rowConfigType, currentIndex)
+				cause = fmt.Errorf("row at picture %d: %w", picsInNextRow[0].Index, ErrLayoutExceedsAvailableHeight)
+				break
 			}
 
 			// Handle case where layout calculation yields zero height (shouldn't happen ideally)
 			if rowLayoutInfo.TotalHeight <= 1e-6 {
-				fmt.Printf("Warning: Calculated row layout for %d pics (type: %s) starting at %d resulted in zero height. Skipping row.\n", numPicturesConsumed, rowConfigType, currentIndex)
-				currentIndex += numPicturesConsumed
-				continue
+				e.warnf("processPictures", "Calculated row layout for %d pics (type: %s) starting at %d resulted in zero height. Deferring the remaining pictures to the fallback path.", numPicturesConsumed, rowConfigType, currentIndex)
+				break
 			}
 
 			// 9. Place the pictures for this row
 			e.placePicturesInRow(picsInNextRow, rowLayoutInfo)
+			e.checkRowMinHeight(picsInNextRow, rowLayoutInfo.TotalHeight)
 
 			// 10. Update Y Coordinate
 			e.currentY += rowLayoutInfo.TotalHeight
@@ -444,8 +573,13 @@ func (e *ContinuousLayoutEngine) processPictures(pictures []Picture) {
 
 	} else {
 		// +++ Use OLD Standard Templated Strategy +++
-		fmt.Println("Debug (ProcessPics): No ultra-wide/tall pictures. Using standard templated layout strategy.")
-		processPicturesOldStrategy(e, pictures)
+		if numPicsTotal > maxTemplatePictures {
+			e.traceRule("processPictures", "Picture groups", fmt.Sprintf("More than %d pictures are placed in groups.", maxTemplatePictures))
+			e.processLargePictureSet(pictures)
+			return
+		}
+		e.debugf("ProcessPics", "No ultra-wide/tall pictures. Using standard templated layout strategy.")
+		cause = processPicturesOldStrategy(e, pictures)
 	}
 }
 
@@ -475,7 +609,7 @@ func (e *ContinuousLayoutEngine) determineNextPictureRow(remainingPics []Picture
 	// 1. Check the first picture
 	pic1 := remainingPics[0]
 	if isUltra(pic1) {
-		fmt.Println("Debug (determineRow): First pic is ultra, forming row-of-1-ultra.")
+		e.debugf("determineRow", "First pic is ultra, forming row-of-1-ultra.")
 		return remainingPics[0:1], "row-of-1-ultra"
 	}
 
@@ -485,10 +619,10 @@ func (e *ContinuousLayoutEngine) determineNextPictureRow(remainingPics []Picture
 		pic3 := remainingPics[2]
 		if !isUltra(pic2) && !isUltra(pic3) {
 			// All three are normal, form a row of 3
-			fmt.Println("Debug (determineRow): First 3 pics are normal, forming row-of-3.")
+			e.debugf("determineRow", "First 3 pics are normal, forming row-of-3.")
 			return remainingPics[0:3], "row-of-3"
 		} else {
-			fmt.Println("Debug (determineRow): Cannot form row-of-3 (pic 2 or 3 is ultra).")
+			e.debugf("determineRow", "Cannot form row-of-3 (pic 2 or 3 is ultra).")
 		}
 	} // Implicitly falls through if less than 3 remain or condition not met
 
@@ -497,22 +631,22 @@ func (e *ContinuousLayoutEngine) determineNextPictureRow(remainingPics []Picture
 		pic2 := remainingPics[1]
 		if !isUltra(pic2) {
 			// Both pic1 and pic2 are normal, form a row of 2
-			fmt.Println("Debug (determineRow): First 2 pics are normal, forming row-of-2.")
+			e.debugf("determineRow", "First 2 pics are normal, forming row-of-2.")
 			return remainingPics[0:2], "row-of-2"
 		} else {
-			fmt.Println("Debug (determineRow): Cannot form row-of-2 (pic 2 is ultra).")
+			e.debugf("determineRow", "Cannot form row-of-2 (pic 2 is ultra).")
 		}
 	} // Implicitly falls through if less than 2 remain or condition not met
 
 	// 4. Default to row of 1 (since pic1 is known to be normal here)
-	fmt.Println("Debug (determineRow): Defaulting to row-of-1 (normal pic).")
+	e.debugf("determineRow", "Defaulting to row-of-1 (normal pic).")
 	return remainingPics[0:1], "row-of-1"
 }
 
 // calculateRowLayout calculates the geometry for a specific row configuration.
 // It *must* respect availableHeight and check minimum heights, returning the best effort layout.
 func (e *ContinuousLayoutEngine) calculateRowLayout(picsInRow []Picture, rowConfigType string, availableHeight float64) (TemplateLayout, error) {
-	fmt.Printf("Debug (calculateRowLayout): Calculating for type '%s', %d pics, availableHeight %.2f\n", rowConfigType, len(picsInRow), availableHeight)
+	e.debugf("calculateRowLayout", "Calculating for type '%s', %d pics, availableHeight %.2f", rowConfigType, len(picsInRow), availableHeight)
 
 	switch rowConfigType {
 	case "row-of-1-ultra", "row-of-1":
@@ -528,7 +662,7 @@ func (e *ContinuousLayoutEngine) calculateRowLayout(picsInRow []Picture, rowConf
 			aspectRatio = float64(pic.Width) / float64(pic.Height)
 			validAR = true
 		} else {
-			fmt.Printf("Warning (calculateRowLayout): Invalid dimensions for picture index %d. Using default AR=1.\n", pic.Index)
+			e.warnf("calculateRowLayout", "Invalid dimensions for picture index %d. Using default AR=1.", pic.Index)
 		}
 
 		picType := GetPictureType(aspectRatio)
@@ -537,14 +671,14 @@ func (e *ContinuousLayoutEngine) calculateRowLayout(picsInRow []Picture, rowConf
 
 		// Use a small positive value for availableHeight if it's near zero to avoid division issues
 		if availableHeight <= 1e-6 {
-			fmt.Printf("Warning (calculateRowLayout): Available height is near zero (%.2f). Cannot calculate layout.\n", availableHeight)
+			e.warnf("calculateRowLayout", "Available height is near zero (%.2f). Cannot calculate layout.", availableHeight)
 			// Return zero-height layout, the caller should handle this.
 			return TemplateLayout{TotalHeight: 0}, errors.New("available height is too small")
 		}
 
 		if !validAR {
 			// Fallback for invalid AR: Use available width and a reasonable capped height
-			fmt.Printf("Warning (calculateRowLayout): Using fallback dimensions for Pic %d due to invalid AR.\n", pic.Index)
+			e.warnf("calculateRowLayout", "Using fallback dimensions for Pic %d due to invalid AR.", pic.Index)
 			finalWidth = e.availableWidth
 			// Estimate height based on min landscape, capped by available
 			finalHeight = math.Min(GetRequiredMinHeight(e, "landscape", 1), availableHeight)
@@ -586,7 +720,7 @@ func (e *ContinuousLayoutEngine) calculateRowLayout(picsInRow []Picture, rowConf
 		// --- Check Minimum Height (Log warning, but don't error out for dynamic layout) ---
 		requiredMinHeight := GetRequiredMinHeight(e, picType, 1) // Check against single pic requirement
 		if finalHeight < requiredMinHeight {
-			fmt.Printf("Warning (calculateRowLayout): Single picture (Index %d, Type %s) layout height %.2f does not meet minimum %.2f.\n", pic.Index, picType, finalHeight, requiredMinHeight)
+			e.warnf("calculateRowLayout", "Single picture (Index %d, Type %s) layout height %.2f does not meet minimum %.2f.", pic.Index, picType, finalHeight, requiredMinHeight)
 		}
 
 		// Return the layout
@@ -635,7 +769,7 @@ func (e *ContinuousLayoutEngine) calculateRowLayout(picsInRow []Picture, rowConf
 			picType := GetPictureType(ar)
 			requiredMinHeight := GetRequiredMinHeight(e, picType, 2) // Check against 2-pic requirement
 			if finalHeight < requiredMinHeight {
-				fmt.Printf("Warning (calculateRowLayout): Row-of-2 picture (Index %d, Type %s) layout height %.2f does not meet minimum %.2f.\n", pic.Index, picType, finalHeight, requiredMinHeight)
+				e.warnf("calculateRowLayout", "Row-of-2 picture (Index %d, Type %s) layout height %.2f does not meet minimum %.2f.", pic.Index, picType, finalHeight, requiredMinHeight)
 			}
 		}
 
@@ -689,7 +823,7 @@ func (e *ContinuousLayoutEngine) calculateRowLayout(picsInRow []Picture, rowConf
 			picType := GetPictureType(ar)
 			requiredMinHeight := GetRequiredMinHeight(e, picType, 3) // Check against 3-pic requirement
 			if finalHeight < requiredMinHeight {
-				fmt.Printf("Warning (calculateRowLayout): Row-of-3 picture (Index %d, Type %s) layout height %.2f does not meet minimum %.2f.\n", pic.Index, picType, finalHeight, requiredMinHeight)
+				e.warnf("calculateRowLayout", "Row-of-3 picture (Index %d, Type %s) layout height %.2f does not meet minimum %.2f.", pic.Index, picType, finalHeight, requiredMinHeight)
 			}
 		}
 
@@ -713,16 +847,16 @@ func (e *ContinuousLayoutEngine) calculateRowLayout(picsInRow []Picture, rowConf
 // placePicturesInRow places the pictures according to the row's calculated layout.
 func (e *ContinuousLayoutEngine) placePicturesInRow(picsInRow []Picture, rowLayout TemplateLayout) {
 	// TODO: Implement placement logic (adapt placePicturesInTemplate)
-	fmt.Printf("Debug (placePicturesInRow): Placing %d pictures. Layout TotalHeight: %.2f\n", len(picsInRow), rowLayout.TotalHeight)
+	e.debugf("placePicturesInRow", "Placing %d pictures. Layout TotalHeight: %.2f", len(picsInRow), rowLayout.TotalHeight)
 
 	if len(picsInRow) != len(rowLayout.Positions) || len(picsInRow) != len(rowLayout.Dimensions) {
-		fmt.Println("Error: Mismatch between picture count and layout information in placePicturesInRow.")
+		e.errorf("placePicturesInRow", "Mismatch between picture count and layout information in placePicturesInRow.")
 		return
 	}
 
 	// Ensure entry exists
 	if len(e.currentPage.Entries) == 0 {
-		fmt.Println("Warning: placePicturesInRow called with no current entry. Creating one.")
+		e.warnf("placePicturesInRow", "placePicturesInRow called with no current entry. Creating one.")
 		e.currentPage.Entries = append(e.currentPage.Entries, PageEntry{})
 	}
 	currentEntry := &e.currentPage.Entries[len(e.currentPage.Entries)-1]
@@ -779,21 +913,23 @@ func (e *ContinuousLayoutEngine) placePicturesInRow(picsInRow []Picture, rowLayo
 			Height: int(math.Round(height)),
 		})
 	}
+	e.tracePlacement("placePicturesInRow", picsInRow, rowLayout.TotalHeight, 0)
 }
 
 // processPicturesOldStrategy contains the original logic using fixed templates
 // Extracted to a separate function for clarity during refactoring
-func processPicturesOldStrategy(e *ContinuousLayoutEngine, pictures []Picture) {
+// It returns why pictures were left unplaced, for the last-resort path of the caller.
+func processPicturesOldStrategy(e *ContinuousLayoutEngine, pictures []Picture) error {
 	numPics := len(pictures)
 	if numPics == 0 { // Should be caught by caller, but double check
-		return
+		return nil
 	}
 
 	// 1. Calculate Required Spacing Before Pictures
 	requiredSpacing := e.requiredSpacingBeforeElement()
 
 	// +++ Log Spacing Info +++
-	fmt.Printf("Debug (OldStrategy): Before spacing. CurrentY: %.2f, RequiredSpacing: %.2f\n", e.currentY, requiredSpacing)
+	e.debugf("OldStrategy", "Before spacing. CurrentY: %.2f, RequiredSpacing: %.2f", e.currentY, requiredSpacing)
 	// +++ End Log +++
 
 	// 2. Centralized Pagination Check
@@ -842,6 +978,7 @@ func processPicturesOldStrategy(e *ContinuousLayoutEngine, pictures []Picture) {
 	} // No pagination needed if current page is empty
 
 	if needsNewPage {
+		e.traceRule("OldStrategy", "New page", "Less than the minimum picture height is left on the page.")
 		e.newPage()
 		requiredSpacing = 0 // No spacing needed at top of new page
 	}
@@ -849,7 +986,7 @@ func processPicturesOldStrategy(e *ContinuousLayoutEngine, pictures []Picture) {
 	// 3. Apply Spacing (potentially on the new page)
 	e.currentY += requiredSpacing
 	// +++ Log Spacing Info +++
-	fmt.Printf("Debug (OldStrategy): After spacing. CurrentY: %.2f\n", e.currentY)
+	e.debugf("OldStrategy", "After spacing. CurrentY: %.2f", e.currentY)
 	// +++ End Log +++
 
 	// 4. Calculate Layout Available Height on the Target Page
@@ -862,46 +999,48 @@ func processPicturesOldStrategy(e *ContinuousLayoutEngine, pictures []Picture) {
 	// +++ 添加日志：记录调用前的页面和Y坐标 +++
 	pageBeforeLayout := e.currentPage.Page
 	yBeforeLayout := e.currentY
-	fmt.Printf("Debug (OldStrategy): Preparing to call layout function for %d pics. Page: %d, CurrentY: %.2f, AvailableH: %.2f\n", len(pictures), pageBeforeLayout, yBeforeLayout, layoutAvailableHeight)
+	e.debugf("OldStrategy", "Preparing to call layout function for %d pics. Page: %d, CurrentY: %.2f, AvailableH: %.2f", len(pictures), pageBeforeLayout, yBeforeLayout, layoutAvailableHeight)
 
 	// 5. Call the appropriate layout processing function based on picture count
 	var actualHeightUsed float64
+	var err error
 	switch numPics {
 	case 1:
-		fmt.Println("Debug (OldStrategy): Calling handler for 1 picture.")
+		e.debugf("OldStrategy", "Calling handler for 1 picture.")
 		actualHeightUsed = e.processSinglePictureLayoutAndPlace(pictures[0], layoutAvailableHeight)
 	case 2:
-		fmt.Println("Debug (OldStrategy): Calling handler for 2 pictures.")
+		e.debugf("OldStrategy", "Calling handler for 2 pictures.")
 		actualHeightUsed = e.processTwoPicturesLayoutAndPlace(pictures, layoutAvailableHeight)
 	default: // 3 or more pictures
-		fmt.Printf("Debug (OldStrategy): Calling processTemplatedLayoutAndPlace for %d pictures.\n", numPics)
-		actualHeightUsed = e.processTemplatedLayoutAndPlace(pictures, layoutAvailableHeight) // Handles 3-9 and signals >9
+		e.debugf("OldStrategy", "Calling processTemplatedLayoutAndPlace for %d pictures.", numPics)
+		actualHeightUsed, err = e.processTemplatedLayoutAndPlace(pictures, layoutAvailableHeight) // Handles 3-9 and signals >9
+		if err != nil {
+			// Whatever was not placed goes to the last-resort path of the caller
+			e.errorf("OldStrategy", "Templated layout of %d pictures failed: %v", numPics, err)
+		}
 	}
 	// +++ 添加日志：记录调用后的页面、Y坐标和返回的高度 +++
 	pageAfterLayout := e.currentPage.Page
 	yAfterLayout := e.currentY
-	fmt.Printf("Debug (OldStrategy): Returned from layout handler. Returned Height: %.2f. Page Before: %d, Page After: %d. Y Before: %.2f, Y After (engine state before update): %.2f\n", actualHeightUsed, pageBeforeLayout, pageAfterLayout, yBeforeLayout, yAfterLayout)
+	e.debugf("OldStrategy", "Returned from layout handler. Returned Height: %.2f. Page Before: %d, Page After: %d. Y Before: %.2f, Y After (engine state before update): %.2f", actualHeightUsed, pageBeforeLayout, pageAfterLayout, yBeforeLayout, yAfterLayout)
 
 	// 6. Update Y Coordinate by Actual Placed Height
 	// The layout function (single, two, or templated via specific functions)
 	// calculates the layout and returns the total height it *should* occupy.
 	// The placement happens relative to the currentY *before* this update.
 	// We now update currentY by the height returned, assuming placement was successful.
-	if actualHeightUsed > 0 { // Only update if a valid height was returned (not 0 or error codes like -2.0)
+	if actualHeightUsed > 0 { // Only update if a valid height was returned (0 when the handler moved currentY itself or failed)
 		e.currentY += actualHeightUsed
-	} else if actualHeightUsed == -2.0 {
-		// Handle split signal if necessary (though pagination should ideally prevent this call)
-		fmt.Println("Debug (OldStrategy): Split signal received from layout function. CurrentY not updated.")
-		// Potentially need logic here if a split during layout requires specific state changes.
 	} else {
 		// Handle other errors or zero height cases
-		fmt.Printf("Debug (OldStrategy): Layout function returned non-positive height (%.2f). CurrentY not updated.\n", actualHeightUsed)
+		e.debugf("OldStrategy", "Layout function returned non-positive height (%.2f). CurrentY not updated.", actualHeightUsed)
 	}
 
 	// +++ 添加日志：记录最终更新后的Y坐标 +++
-	fmt.Printf("Debug (OldStrategy): Final CurrentY after potential update: %.2f\n", e.currentY)
+	e.debugf("OldStrategy", "Final CurrentY after potential update: %.2f", e.currentY)
 
 	// Spacing *after* pictures is handled by the *next* element/entry's requiredSpacingBeforeElement check.
+	return err
 }
 
 // calculateUniformRowLayout calculates the dimensions for a row of pictures aiming for a uniform height,
@@ -923,7 +1062,7 @@ func (e *ContinuousLayoutEngine) calculateUniformRowLayout(picsInRow []Picture)
 	for i, pic := range picsInRow {
 		if pic.Height <= 0 || pic.Width <= 0 {
 			// Handle invalid dimensions - default to AR=1?
-			fmt.Printf("Warning (calculateUniformRowLayout): Invalid dimensions for pic index %d. Using AR=1.\n", pic.Index)
+			e.warnf("calculateUniformRowLayout", "Invalid dimensions for pic index %d. Using AR=1.", pic.Index)
 			ARs[i] = 1.0
 		} else {
 			ARs[i] = float64(pic.Width) / float64(pic.Height)
@@ -989,47 +1128,47 @@ func (e *ContinuousLayoutEngine) requiredSpacingBeforeElement() float64 {
 }
 
 // processTemplatedLayoutAndPlace handles layout for 3+ pictures using dedicated functions.
-func (e *ContinuousLayoutEngine) processTemplatedLayoutAndPlace(pictures []Picture, layoutAvailableHeight float64) float64 {
+// It returns the height to add to currentY, which is 0 when the pictures went across
+// pages and currentY already moved. Pictures left unplaced are reported with an error
+// from unplacedError, and picture counts without templates with ErrSplitRequired.
+func (e *ContinuousLayoutEngine) processTemplatedLayoutAndPlace(pictures []Picture, layoutAvailableHeight float64) (float64, error) {
 	numPics := len(pictures)
-	if numPics < 3 {
-		fmt.Printf("Error: processTemplatedLayoutAndPlace called with %d pictures. Needs >= 3. Skipping.\n", numPics)
-		return 0
+	if numPics < minTemplatePictures {
+		return 0, fmt.Errorf("templated layout needs at least %d pictures, got %d: %w", minTemplatePictures, numPics, ErrSplitRequired)
 	}
 
 	switch numPics {
 	case 3:
 		// --- UPDATED: Call new function with split logic ---
-		fmt.Println("Debug (TemplateDispatch): Calling processThreePicturesWithSplitLogic for 3 pictures.")
+		e.debugf("TemplateDispatch", "Calling processThreePicturesWithSplitLogic for 3 pictures.")
 		return e.processThreePicturesWithSplitLogic(pictures, layoutAvailableHeight)
 	case 4:
 		// --- UPDATED: Call new function with split logic ---
-		fmt.Println("Debug (TemplateDispatch): Calling processFourPicturesWithSplitLogic for 4 pictures.")
+		e.debugf("TemplateDispatch", "Calling processFourPicturesWithSplitLogic for 4 pictures.")
 		return e.processFourPicturesWithSplitLogic(pictures, layoutAvailableHeight)
 	case 5:
 		// --- UPDATED: Call new function with split logic ---
-		fmt.Println("Debug (TemplateDispatch): Calling processFivePicturesWithSplitLogic for 5 pictures.")
+		e.debugf("TemplateDispatch", "Calling processFivePicturesWithSplitLogic for 5 pictures.")
 		return e.processFivePicturesWithSplitLogic(pictures, layoutAvailableHeight)
 	case 6:
 		// --- UPDATED: Call new function with split logic ---
-		fmt.Println("Debug (TemplateDispatch): Calling processSixPicturesWithSplitLogic for 6 pictures.")
+		e.debugf("TemplateDispatch", "Calling processSixPicturesWithSplitLogic for 6 pictures.")
 		return e.processSixPicturesWithSplitLogic(pictures, layoutAvailableHeight)
 	case 7:
 		// --- CORRECTED: Call the function with split logic ---
-		fmt.Println("Debug (TemplateDispatch): Calling processSevenPicturesWithSplitLogic for 7 pictures.")
+		e.debugf("TemplateDispatch", "Calling processSevenPicturesWithSplitLogic for 7 pictures.")
 		return e.processSevenPicturesWithSplitLogic(pictures, layoutAvailableHeight)
 	case 8:
 		// --- UPDATED: Call new function with split logic ---
-		fmt.Println("Debug (TemplateDispatch): Calling processEightPicturesWithSplitLogic for 8 pictures.")
+		e.debugf("TemplateDispatch", "Calling processEightPicturesWithSplitLogic for 8 pictures.")
 		return e.processEightPicturesWithSplitLogic(pictures, layoutAvailableHeight)
 	case 9:
 		// --- UPDATED: Call new function with split logic ---
-		fmt.Println("Debug (TemplateDispatch): Calling processNinePicturesWithSplitLogic for 9 pictures.")
+		e.debugf("TemplateDispatch", "Calling processNinePicturesWithSplitLogic for 9 pictures.")
 		return e.processNinePicturesWithSplitLogic(pictures, layoutAvailableHeight)
 	default:
 		// For > 9 pictures, we currently don't have specific layouts. Signal split.
-		fmt.Printf("Debug: No specific layout defined for %d pictures. Signaling split by returning error value -2.0.\n", numPics)
-		// Treat this as a split signal, similar to how calculation functions might return split_required error.
-		return -2.0 // Signal split_required
+		return 0, fmt.Errorf("no layout defined for %d pictures: %w", numPics, ErrSplitRequired)
 	}
 
 	// Note: The common error handling and placement logic previously here is now integrated
@@ -1039,7 +1178,7 @@ func (e *ContinuousLayoutEngine) processTemplatedLayoutAndPlace(pictures []Pictu
 // placePicturesInTemplate places pictures based on the calculated template layout.
 func (e *ContinuousLayoutEngine) placePicturesInTemplate(pictures []Picture, layout TemplateLayout) {
 	if len(pictures) != len(layout.Positions) || len(pictures) != len(layout.Dimensions) {
-		fmt.Println("Error: Mismatch between picture count and layout information in placePicturesInTemplate.")
+		e.errorf("placePicturesInTemplate", "Mismatch between picture count and layout information in placePicturesInTemplate.")
 		return
 	}
 
@@ -1047,14 +1186,14 @@ func (e *ContinuousLayoutEngine) placePicturesInTemplate(pictures []Picture, lay
 	if len(e.currentPage.Entries) == 0 {
 		// This should ideally not happen if placement is always preceded by entry creation/selection.
 		// If it does, create a temporary placeholder entry. This might indicate a logic flaw elsewhere.
-		fmt.Println("Warning: placePicturesInTemplate called with no current entry on the page. Creating one.")
+		e.warnf("placePicturesInTemplate", "placePicturesInTemplate called with no current entry on the page. Creating one.")
 		e.currentPage.Entries = append(e.currentPage.Entries, PageEntry{})
 	}
 	currentEntry := &e.currentPage.Entries[len(e.currentPage.Entries)-1]
 	startY := e.currentY // Top Y coordinate for the *entire* layout block
 
 	// +++ Log StartY +++
-	fmt.Printf("Debug (Place): Inside placePicturesInTemplate. startY (e.currentY): %.2f\n", startY)
+	e.debugf("Place", "Inside placePicturesInTemplate. startY (e.currentY): %.2f", startY)
 	// +++ End Log +++
 
 	// --- Calculate Actual Width and Centering Offset ---
@@ -1068,6 +1207,11 @@ func (e *ContinuousLayoutEngine) placePicturesInTemplate(pictures []Picture, lay
 		}
 	}
 
+	if layout.TotalWidth > 0 && actualScaledWidth < layout.TotalWidth {
+		// Templates span the full width; a narrower block was scaled down to fit.
+		e.scaleLoss += 1 - actualScaledWidth/layout.TotalWidth
+	}
+
 	offsetX := 0.0
 	if actualScaledWidth < e.availableWidth {
 		offsetX = (e.availableWidth - actualScaledWidth) / 2.0
@@ -1093,6 +1237,11 @@ func (e *ContinuousLayoutEngine) placePicturesInTemplate(pictures []Picture, lay
 			{absX0, absY0},
 			{absX1, absY1},
 		}
+		crop := e.cropRect(pic, width, height)
+		focus := pic.Focus
+		if crop != nil {
+			focus = e.pictureFocus(pic) // report detected focal points of cropped pictures
+		}
 		// Ensure Pictures slice is initialized if nil
 		if currentEntry.Pictures == nil {
 			currentEntry.Pictures = make([]Picture, 0, len(pictures))
@@ -1103,7 +1252,14 @@ func (e *ContinuousLayoutEngine) placePicturesInTemplate(pictures []Picture, lay
 			URL:    pic.URL,
 			Width:  int(math.Round(width)),  // Store final rounded layout width
 			Height: int(math.Round(height)), // Store final rounded layout height
+			Crop:   crop,
+			Focus:  focus,
 		})
 	}
+	scale := 1.0
+	if layout.TotalWidth > 0 {
+		scale = actualScaledWidth / layout.TotalWidth
+	}
+	e.tracePlacement("placePicturesInTemplate", pictures, layout.TotalHeight, scale)
 	// currentY updated by caller (e.g., processTemplatedLayoutAndPlace)
 }
@@ -0,0 +1,88 @@
+package waterfall
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors of the layout engine. The layout functions return them wrapped with details,
+// so test for them with errors.Is.
+var (
+	// ErrMinHeightConstraint reports pictures that would be placed below their minimum
+	// height.
+	ErrMinHeightConstraint = errors.New("minimum height constraint violated")
+	// ErrLayoutExceedsAvailableHeight indicates the calculated layout cannot fit.
+	ErrLayoutExceedsAvailableHeight = errors.New("calculated layout height exceeds available height")
+	// ErrSplitRequired reports that no layout fits the pictures as one group, so they
+	// have to be split into smaller groups.
+	ErrSplitRequired = errors.New("split required")
+	// ErrNewPageRequired reports that no layout fits the pictures on the current page,
+	// and that they are kept together on a new page rather than split.
+	ErrNewPageRequired = errors.New("new page required")
+	// ErrInvalidDimensions reports a picture without a positive width and height.
+	ErrInvalidDimensions = errors.New("invalid picture dimensions")
+	// ErrPicturesNotPlaced reports pictures the layout strategies failed to place, so
+	// the last-resort path placed them in a forced row.
+	ErrPicturesNotPlaced = errors.New("pictures not placed by the layout strategies")
+)
+
+// LayoutError reports content the engine could only lay out by degrading it: placing
+// pictures below their minimum height, in a forced row, or with a made-up aspect
+// ratio. In strict mode ProcessEntries fails with it; otherwise the layout goes on and
+// the degradation shows in the page's relaxations or the log.
+type LayoutError struct {
+	EntryID  int64
+	Page     int
+	Pictures []int // Picture.Index of the affected pictures
+	// Err is one of the errors above, possibly wrapped with details.
+	Err error
+}
+
+func (e *LayoutError) Error() string {
+	return fmt.Sprintf("entry %d, page %d, pictures %v: %v", e.EntryID, e.Page, e.Pictures, e.Err)
+}
+
+func (e *LayoutError) Unwrap() error {
+	return e.Err
+}
+
+// degrade reports that pictures were laid out only by relaxing the configuration, for
+// the reason err wraps. In strict mode the first report fails the layout once the
+// entry being laid out is done.
+func (e *ContinuousLayoutEngine) degrade(pictures []int, err error) {
+	if !e.config.Strict || e.strictErr != nil {
+		return
+	}
+	layoutErr := &LayoutError{Pictures: pictures, Err: err}
+	if e.activeEntry != nil {
+		layoutErr.EntryID = e.activeEntry.ID
+	}
+	if e.currentPage != nil {
+		layoutErr.Page = e.currentPage.Page
+	}
+	e.strictErr = layoutErr
+}
+
+// checkPictureDimensions reports the pictures without a positive width and height.
+// The layout functions give them an aspect ratio of 1.
+func (e *ContinuousLayoutEngine) checkPictureDimensions(pictures []Picture) {
+	for _, pic := range pictures {
+		if pic.Width <= 0 || pic.Height <= 0 {
+			e.degrade([]int{pic.Index}, fmt.Errorf("picture %d is %dx%d: %w", pic.Index, pic.Width, pic.Height, ErrInvalidDimensions))
+		}
+	}
+}
+
+// unplacedError reports the pictures a split rule leaves to the fallback path because
+// they do not fit even a fresh page. It wraps ErrSplitRequired and err, the error of
+// the rule's layout calculation, or ErrLayoutExceedsAvailableHeight if err is nil
+// because the calculated layout was too tall.
+func unplacedError(rule string, pictures []Picture, err error) error {
+	if err == nil {
+		err = ErrLayoutExceedsAvailableHeight
+	}
+	if !errors.Is(err, ErrSplitRequired) {
+		err = fmt.Errorf("%w: %w", ErrSplitRequired, err)
+	}
+	return fmt.Errorf("%s: pictures %d-%d do not fit a fresh page: %w", rule, pictures[0].Index, pictures[len(pictures)-1].Index, err)
+}
@@ -0,0 +1,76 @@
+package waterfall
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestStrictModeUnplaceablePictures(t *testing.T) {
+	entries := []Entry{
+		{ID: 7, Time: "2025年3月30日 18:00", Text: "第一条", Pictures: fallbackPictures(4, 0, 0)},
+	}
+	cfg := unplaceableConfig(true)
+
+	e := NewContinuousLayoutEngineWithConfig(entries, cfg)
+	e.logger = discardLogger
+	if _, err := e.ProcessEntries(); err != nil {
+		t.Fatalf("non-strict layout failed: %v", err)
+	}
+
+	cfg.Strict = true
+	e = NewContinuousLayoutEngineWithConfig(entries, cfg)
+	e.logger = discardLogger
+	_, err := e.ProcessEntries()
+	var layoutErr *LayoutError
+	if !errors.As(err, &layoutErr) {
+		t.Fatalf("strict layout returned %v, want a *LayoutError", err)
+	}
+	if layoutErr.EntryID != 7 {
+		t.Errorf("EntryID = %d, want 7", layoutErr.EntryID)
+	}
+	if len(layoutErr.Pictures) == 0 {
+		t.Error("LayoutError lists no pictures")
+	}
+	for _, target := range []error{ErrPicturesNotPlaced, ErrSplitRequired, ErrMinHeightConstraint} {
+		if !errors.Is(err, target) {
+			t.Errorf("errors.Is(%v, %v) = false", err, target)
+		}
+	}
+}
+
+func TestSplitRuleErrors(t *testing.T) {
+	e := NewContinuousLayoutEngineWithConfig(nil, unplaceableConfig(true))
+	e.logger = discardLogger
+	e.newPage()
+
+	height, err := e.processFourPicturesWithSplitLogic(fallbackPictures(4, 0, 0), e.availableHeight)
+	if height != 0 {
+		t.Errorf("height = %.2f, want 0", height)
+	}
+	if !errors.Is(err, ErrSplitRequired) || !errors.Is(err, ErrMinHeightConstraint) {
+		t.Errorf("err = %v, want ErrSplitRequired and ErrMinHeightConstraint", err)
+	}
+
+	if _, err := e.processFourPicturesWithSplitLogic(fallbackPictures(3, 0, 0), e.availableHeight); err == nil {
+		t.Error("no error for 3 pictures")
+	}
+}
+
+func TestStrictModeInvalidDimensions(t *testing.T) {
+	entries := []Entry{
+		{ID: 3, Time: "2025年3月30日 18:00", Pictures: []Picture{{Index: 0, Width: 1600, Height: 1200}, {Index: 1}}},
+	}
+	cfg := DefaultLayoutConfig()
+	cfg.Strict = true
+	e := NewContinuousLayoutEngineWithConfig(entries, cfg)
+	e.logger = discardLogger
+	_, err := e.ProcessEntries()
+	var layoutErr *LayoutError
+	if !errors.As(err, &layoutErr) || !errors.Is(err, ErrInvalidDimensions) {
+		t.Fatalf("err = %v, want a *LayoutError wrapping ErrInvalidDimensions", err)
+	}
+	if !reflect.DeepEqual(layoutErr.Pictures, []int{1}) {
+		t.Errorf("Pictures = %v, want [1]", layoutErr.Pictures)
+	}
+}
@@ -0,0 +1,73 @@
+package waterfall
+
+import "slices"
+
+// Explanation tells how the pictures of one entry were laid out, from a LayoutTrace.
+type Explanation struct {
+	EntryID    int64                  `json:"entry_id"`
+	Placements []PlacementExplanation `json:"placements"`
+	// Decisions lists everything traced for the entry, in order.
+	Decisions []TraceDecision `json:"decisions"`
+}
+
+// PlacementExplanation describes one block of pictures placed on a page. Lengths are
+// in layout pixels.
+type PlacementExplanation struct {
+	Page     int     `json:"page"`
+	Pictures []int   `json:"pictures"` // Picture.Index of the placed pictures
+	Height   float64 `json:"height"`
+	Scale    float64 `json:"scale,omitempty"` // share of the full width a template block kept
+	Source   string  `json:"source"`          // function that placed the block
+	// Rule is the last rule applied before the block was placed, e.g. "Rule 3: Placing
+	// 4 pics (H: 2237.92) on new page.", or empty when the entry's pictures were placed
+	// without one.
+	Rule string `json:"rule,omitempty"`
+	// Choice holds the template candidates rated for the placed pictures, with their
+	// scores and minimum height checks. It is nil for blocks not placed by a template.
+	Choice *TemplateChoice `json:"choice,omitempty"`
+}
+
+// Explain returns the explanation of the entry with the given ID, and false if the
+// trace has no such entry.
+func (t *LayoutTrace) Explain(entryID int64) (Explanation, bool) {
+	for _, entry := range t.Entries {
+		if entry.EntryID == entryID {
+			return explainEntry(entry), true
+		}
+	}
+	return Explanation{}, false
+}
+
+// explainEntry pairs every placement of entry with the rule and the template choice
+// that led to it: the last rule traced before it, and the last choice rated for the
+// same pictures that picked a template.
+func explainEntry(entry EntryTrace) Explanation {
+	ex := Explanation{EntryID: entry.EntryID, Placements: []PlacementExplanation{}, Decisions: entry.Decisions}
+	var rule string
+	for i, d := range entry.Decisions {
+		switch d.Kind {
+		case TraceRule:
+			rule = d.Rule
+			if d.Detail != "" {
+				rule += ": " + d.Detail
+			}
+		case TracePlacement:
+			placement := PlacementExplanation{
+				Page:     d.Page,
+				Pictures: d.Pictures,
+				Height:   d.Height,
+				Scale:    d.Scale,
+				Source:   d.Source,
+				Rule:     rule,
+			}
+			for j := i - 1; j >= 0; j-- {
+				if c := entry.Decisions[j]; c.Kind == TraceTemplateChoice && c.Choice.Chosen != "" && slices.Equal(c.Pictures, d.Pictures) {
+					placement.Choice = c.Choice
+					break
+				}
+			}
+			ex.Placements = append(ex.Placements, placement)
+		}
+	}
+	return ex
+}
@@ -0,0 +1,73 @@
+package waterfall
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExplainEntry(t *testing.T) {
+	all := &TemplateChoice{Chosen: "4_22", Candidates: []CandidateScore{{Template: "4_22", Valid: true}}}
+	none := &TemplateChoice{Candidates: []CandidateScore{{Template: "4_22", Error: "minimum height not met"}}}
+	first := &TemplateChoice{Chosen: "up_down"}
+	rule := func(rule, detail string) TraceDecision {
+		return TraceDecision{Kind: TraceRule, Rule: rule, Detail: detail}
+	}
+	choice := func(pictures []int, c *TemplateChoice) TraceDecision {
+		return TraceDecision{Kind: TraceTemplateChoice, Pictures: pictures, Choice: c}
+	}
+	placement := func(page int, pictures []int) TraceDecision {
+		return TraceDecision{Kind: TracePlacement, Page: page, Pictures: pictures, Height: 500, Source: "placePicturesInTemplate"}
+	}
+
+	tests := []struct {
+		name      string
+		decisions []TraceDecision
+		want      []PlacementExplanation
+	}{
+		{"no placements", []TraceDecision{rule("KeepTogether", "")}, []PlacementExplanation{}},
+		{
+			"placed without a rule",
+			[]TraceDecision{choice([]int{0, 1, 2, 3}, all), placement(1, []int{0, 1, 2, 3})},
+			[]PlacementExplanation{{Page: 1, Pictures: []int{0, 1, 2, 3}, Height: 500, Source: "placePicturesInTemplate", Choice: all}},
+		},
+		{
+			"last rule and matching choice",
+			[]TraceDecision{
+				choice([]int{0, 1, 2, 3}, none),
+				rule("Rule 1", "failed"),
+				choice([]int{0, 1}, first),
+				rule("Rule 4", "Placing G1 on new page."),
+				placement(2, []int{0, 1}),
+				choice([]int{2, 3}, &TemplateChoice{}),
+				placement(2, []int{2, 3}),
+			},
+			[]PlacementExplanation{
+				{Page: 2, Pictures: []int{0, 1}, Height: 500, Source: "placePicturesInTemplate", Rule: "Rule 4: Placing G1 on new page.", Choice: first},
+				{Page: 2, Pictures: []int{2, 3}, Height: 500, Source: "placePicturesInTemplate", Rule: "Rule 4: Placing G1 on new page."},
+			},
+		},
+		{
+			"choice that picked no template",
+			[]TraceDecision{choice([]int{0, 1, 2, 3}, none), placement(1, []int{0, 1, 2, 3})},
+			[]PlacementExplanation{{Page: 1, Pictures: []int{0, 1, 2, 3}, Height: 500, Source: "placePicturesInTemplate"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ex := explainEntry(EntryTrace{EntryID: 9, Decisions: tt.decisions})
+			if ex.EntryID != 9 || len(ex.Decisions) != len(tt.decisions) {
+				t.Errorf("explanation of entry %d with %d decisions, want entry 9 with %d", ex.EntryID, len(ex.Decisions), len(tt.decisions))
+			}
+			if !reflect.DeepEqual(ex.Placements, tt.want) {
+				t.Errorf("placements\n%+v\nwant\n%+v", ex.Placements, tt.want)
+			}
+		})
+	}
+}
+
+func TestExplainUnknownEntry(t *testing.T) {
+	trace := LayoutTrace{Entries: []EntryTrace{{EntryID: 1}}}
+	if _, ok := trace.Explain(2); ok {
+		t.Error("Explain found an entry that was not traced")
+	}
+}
@@ -0,0 +1,161 @@
+package waterfall
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Minimum number of letters kept before and after a hyphenation point (TeX defaults for English).
+const (
+	hyphenLeftMin  = 2
+	hyphenRightMin = 3
+)
+
+// Hyphenator finds hyphenation points with Liang's algorithm, using TeX-style
+// patterns such as the hyph-utf8 files (hyph-en-us.pat.txt).
+type Hyphenator struct {
+	patterns   map[string][]int // letters -> inter-letter values, len(letters)+1
+	exceptions map[string][]int // word -> hyphenation points
+	maxLen     int              // longest pattern, in runes
+}
+
+// LoadHyphenator reads a pattern file. See ParseHyphenationPatterns for the format.
+func LoadHyphenator(path string) (*Hyphenator, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	h, err := ParseHyphenationPatterns(f)
+	if err != nil {
+		return nil, fmt.Errorf("hyphenation patterns %s: %w", path, err)
+	}
+	return h, nil
+}
+
+// ParseHyphenationPatterns reads whitespace-separated patterns like "a1b" or ".ach4".
+// Tokens with hyphens and no digits ("hy-phen-ation") are exceptions that list a
+// word's hyphenation points explicitly. Lines starting with % are comments.
+func ParseHyphenationPatterns(r io.Reader) (*Hyphenator, error) {
+	h := &Hyphenator{
+		patterns:   make(map[string][]int),
+		exceptions: make(map[string][]int),
+	}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "%") {
+			continue
+		}
+		for _, token := range strings.Fields(strings.ToLower(line)) {
+			if strings.Contains(token, "-") && !strings.ContainsAny(token, "0123456789") {
+				h.addException(token)
+			} else {
+				h.addPattern(token)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(h.patterns) == 0 && len(h.exceptions) == 0 {
+		return nil, fmt.Errorf("no patterns found")
+	}
+	return h, nil
+}
+
+func (h *Hyphenator) addPattern(token string) {
+	var letters []rune
+	values := []int{0}
+	for _, r := range token {
+		if r >= '0' && r <= '9' {
+			values[len(values)-1] = int(r - '0')
+			continue
+		}
+		letters = append(letters, r)
+		values = append(values, 0)
+	}
+	if len(letters) == 0 {
+		return
+	}
+	h.patterns[string(letters)] = values
+	if len(letters) > h.maxLen {
+		h.maxLen = len(letters)
+	}
+}
+
+func (h *Hyphenator) addException(token string) {
+	var letters []rune
+	var points []int
+	for _, r := range token {
+		if r == '-' {
+			points = append(points, len(letters))
+			continue
+		}
+		letters = append(letters, r)
+	}
+	h.exceptions[string(letters)] = points
+}
+
+// Hyphenate returns the rune offsets at which word may be split, in increasing order.
+func (h *Hyphenator) Hyphenate(word string) []int {
+	lower := []rune(strings.ToLower(word))
+	if len(lower) < hyphenLeftMin+hyphenRightMin {
+		return nil
+	}
+	if points, ok := h.exceptions[string(lower)]; ok {
+		return points
+	}
+
+	// Pad with word-boundary dots and take the maximum value of every matching pattern.
+	w := append(append([]rune{'.'}, lower...), '.')
+	values := make([]int, len(w)+1)
+	for i := range w {
+		for j := i + 1; j <= len(w) && j-i <= h.maxLen; j++ {
+			pattern, ok := h.patterns[string(w[i:j])]
+			if !ok {
+				continue
+			}
+			for k, v := range pattern {
+				if v > values[i+k] {
+					values[i+k] = v
+				}
+			}
+		}
+	}
+
+	var points []int
+	for i := hyphenLeftMin; i <= len(lower)-hyphenRightMin; i++ {
+		// values[i+1] sits before lower[i] because of the leading dot.
+		if values[i+1]%2 == 1 {
+			points = append(points, i)
+		}
+	}
+	return points
+}
+
+// hyphenators caches pattern files by path.
+var hyphenators sync.Map // path -> *Hyphenator
+
+func cachedHyphenator(path string) (*Hyphenator, error) {
+	if h, ok := hyphenators.Load(path); ok {
+		return h.(*Hyphenator), nil
+	}
+	h, err := LoadHyphenator(path)
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := hyphenators.LoadOrStore(path, h)
+	return actual.(*Hyphenator), nil
+}
+
+// isWordRune reports whether r belongs to a Latin-script word, i.e. a run that must not
+// be broken without a hyphen. CJK characters, emoji and whitespace are not word runes.
+func isWordRune(r rune) bool {
+	return r < 0x2E80 && !unicode.IsSpace(r)
+}
@@ -0,0 +1,42 @@
+package waterfall
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Patterns from Liang's thesis that hyphenate "hyphenation".
+const testHyphenationPatterns = `% comment line
+hy3ph he2n hena4 hen5at 1na n2at 1tio 2io o2n
+ta-ble`
+
+func TestHyphenate(t *testing.T) {
+	h, err := ParseHyphenationPatterns(strings.NewReader(testHyphenationPatterns))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		word string
+		want []int
+	}{
+		{"hyphenation", []int{2, 6}},
+		{"Hyphenation", []int{2, 6}},
+		{"table", []int{2}}, // exception
+		{"hyph", nil},       // shorter than the left and right minimums
+		{"nation", []int{2}},
+		{"anaxyz", nil}, // "1na" would leave one letter before the point
+		{"abcna", nil},  // "1na" would leave two letters after the point
+	}
+	for _, tt := range tests {
+		if got := h.Hyphenate(tt.word); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Hyphenate(%q) = %v, want %v", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestParseHyphenationPatternsEmpty(t *testing.T) {
+	if _, err := ParseHyphenationPatterns(strings.NewReader("% only a comment\n")); err == nil {
+		t.Error("no error for a file without patterns")
+	}
+}
@@ -0,0 +1,345 @@
+package waterfall
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// EmoticonConfig controls rendering of WeChat emoticon codes ([微笑]) and Unicode emoji
+// as inline images.
+type EmoticonConfig struct {
+	Enabled bool `json:"enabled" yaml:"enabled"`
+	// Dir is the local directory holding the images. A code without an entry in Codes
+	// resolves to "<name>.png" in Dir, e.g. [微笑] -> 微笑.png.
+	Dir       string            `json:"dir" yaml:"dir"`
+	URLPrefix string            `json:"url_prefix" yaml:"url_prefix"` // URL under which Dir is served
+	Codes     map[string]string `json:"codes" yaml:"codes"`           // 表情代码 -> 图片文件名，如 "[微笑]": "weixiao.png"
+	// Emoji renders Unicode emoji as images named by their code points in lower-case
+	// hex, joined with "-" (twemoji naming, e.g. 1f600.png, 1f468-200d-1f469.png).
+	Emoji bool    `json:"emoji" yaml:"emoji"`
+	Size  float64 `json:"size" yaml:"size"` // 表情图片边长，相对字号的倍数
+}
+
+// DefaultEmoticonConfig returns the emoticon settings with inline images switched off.
+func DefaultEmoticonConfig() EmoticonConfig {
+	return EmoticonConfig{
+		URLPrefix: "/emoticons/",
+		Size:      1,
+	}
+}
+
+// InlineRun is a piece of a laid-out text line: a run of plain text or a single
+// inline image glyph. Area is the run's box in page coordinates.
+type InlineRun struct {
+	Text  string      `json:"text,omitempty"`
+	Image string      `json:"image,omitempty"` // 图片URL
+	Code  string      `json:"code,omitempty"`  // 原文，如 [微笑] 或 emoji
+	Area  [][]float64 `json:"area"`
+	// Compressed lists the rune offsets in Text of the punctuation set at half its
+	// width, see PageEntry.CompressedPunctuation.
+	Compressed []int `json:"compressed,omitempty"`
+}
+
+// inlineGlyph is a resolved emoticon or emoji. While wrapping, each distinct glyph
+// is represented by one rune from the Supplementary Private Use Area, so the line
+// breaker can treat it as a single unbreakable character. Characters of the text in
+// that area get a placeholder of their own, a glyph without image, so they are not
+// taken for other glyphs and come back unchanged.
+type inlineGlyph struct {
+	code  string
+	image string // "" for a character of the text
+}
+
+const (
+	inlineGlyphBase = 0xF0000 // Supplementary Private Use Area-A
+	inlineGlyphLast = 0xFFFFD
+)
+
+var emoticonCodePattern = regexp.MustCompile(`\[[^\[\]\s]{1,8}\]`)
+
+// inlineImages resolves and tracks the glyphs used by one engine.
+type inlineImages struct {
+	config EmoticonConfig
+	byCode map[string]rune // resolved code -> placeholder rune; 0 when unresolvable
+	glyphs []inlineGlyph   // indexed by placeholder - inlineGlyphBase
+}
+
+func newInlineImages(cfg EmoticonConfig) *inlineImages {
+	if !cfg.Enabled {
+		return nil
+	}
+	return &inlineImages{config: cfg, byCode: make(map[string]rune)}
+}
+
+// substitute replaces every resolvable emoticon code and emoji in text by its
+// placeholder rune. Unresolvable ones are left as text. Characters of the text in the
+// placeholder range are replaced by placeholders of their own, which expand restores.
+func (in *inlineImages) substitute(text string) string {
+	if in == nil {
+		return text
+	}
+	text = strings.Map(func(r rune) rune {
+		if r < inlineGlyphBase || r > inlineGlyphLast {
+			return r
+		}
+		if p := in.placeholder(string(r), ""); p != 0 {
+			return p
+		}
+		return utf8.RuneError // no placeholder left
+	}, text)
+	text = emoticonCodePattern.ReplaceAllStringFunc(text, func(code string) string {
+		if r := in.placeholder(code, in.emoticonFile(code)); r != 0 {
+			return string(r)
+		}
+		return code
+	})
+	if !in.config.Emoji {
+		return text
+	}
+
+	var b strings.Builder
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		n := emojiSequenceLength(runes[i:])
+		if n == 0 {
+			b.WriteRune(runes[i])
+			i++
+			continue
+		}
+		code := string(runes[i : i+n])
+		if r := in.placeholder(code, in.emojiFile(runes[i:i+n])); r != 0 {
+			b.WriteRune(r)
+		} else {
+			b.WriteString(code)
+		}
+		i += n
+	}
+	return b.String()
+}
+
+// placeholder returns the rune standing for code, registering it on first use.
+// A code with an empty file is a character of the text in the placeholder range;
+// placeholder returns 0 for other codes without image, and when the range is used up.
+func (in *inlineImages) placeholder(code, file string) rune {
+	if r, ok := in.byCode[code]; ok {
+		return r
+	}
+	var r rune
+	literal := utf8.RuneCountInString(code) == 1 && []rune(code)[0] >= inlineGlyphBase
+	if (file != "" || literal) && inlineGlyphBase+len(in.glyphs) <= inlineGlyphLast {
+		r = inlineGlyphBase + rune(len(in.glyphs))
+		glyph := inlineGlyph{code: code}
+		if file != "" {
+			glyph.image = path.Join(in.config.URLPrefix, file)
+		}
+		in.glyphs = append(in.glyphs, glyph)
+	}
+	in.byCode[code] = r
+	return r
+}
+
+// lookup returns the glyph or text character a placeholder rune stands for.
+func (in *inlineImages) lookup(r rune) (inlineGlyph, bool) {
+	if in == nil {
+		return inlineGlyph{}, false
+	}
+	i := int(r - inlineGlyphBase)
+	if i < 0 || i >= len(in.glyphs) {
+		return inlineGlyph{}, false
+	}
+	return in.glyphs[i], true
+}
+
+// glyph returns the image glyph a placeholder rune stands for.
+func (in *inlineImages) glyph(r rune) (inlineGlyph, bool) {
+	g, ok := in.lookup(r)
+	return g, ok && g.image != ""
+}
+
+// source returns the character of the text a placeholder rune stands for, or r.
+func (in *inlineImages) source(r rune) rune {
+	if g, ok := in.lookup(r); ok && g.image == "" {
+		return []rune(g.code)[0]
+	}
+	return r
+}
+
+// expand replaces placeholder runes in s by their source text.
+func (in *inlineImages) expand(s string) string {
+	if in == nil || len(in.glyphs) == 0 {
+		return s
+	}
+	var b strings.Builder
+	for _, r := range s {
+		if g, ok := in.lookup(r); ok {
+			b.WriteString(g.code)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// advance measures r for line breaking, giving inline glyphs their image width.
+func (e *ContinuousLayoutEngine) advance(r rune) float64 {
+	if _, ok := e.inline.glyph(r); ok {
+		return e.inline.config.Size * e.fontSize
+	}
+	return e.measurer.Advance(e.inline.source(r), e.fontSize)
+}
+
+// inlineRuns splits wrapped lines into text runs and image glyphs with page
+// positions. The first line starts at startY.
+func (e *ContinuousLayoutEngine) inlineRuns(lines []string, startY float64) []InlineRun {
+	var runs []InlineRun
+	glyphSize := e.inline.config.Size * e.fontSize
+	for k, line := range lines {
+		top := startY + float64(k)*e.lineHeight
+		runes := []rune(line)
+		widths := make([]float64, len(runes))
+		for i, r := range runes {
+			widths[i] = e.advance(r)
+		}
+		compressed := e.lineBreaker.compressed(runes)
+		for _, i := range compressed {
+			widths[i] /= 2
+		}
+
+		x := e.marginLeft
+		textStart, textX := 0, x
+		flush := func(end int) {
+			if end > textStart {
+				run := InlineRun{
+					Text: e.inline.expand(string(runes[textStart:end])),
+					Area: [][]float64{{textX, top}, {x, top + e.lineHeight}},
+				}
+				for _, i := range compressed {
+					if i >= textStart && i < end {
+						run.Compressed = append(run.Compressed, i-textStart)
+					}
+				}
+				runs = append(runs, run)
+			}
+		}
+		for i, r := range runes {
+			g, ok := e.inline.glyph(r)
+			if !ok {
+				x += widths[i]
+				continue
+			}
+			flush(i)
+			glyphTop := top + (e.lineHeight-glyphSize)/2
+			runs = append(runs, InlineRun{
+				Image: g.image,
+				Code:  g.code,
+				Area:  [][]float64{{x, glyphTop}, {x + glyphSize, glyphTop + glyphSize}},
+			})
+			x += widths[i]
+			textStart, textX = i+1, x
+		}
+		flush(len(runes))
+	}
+	return runs
+}
+
+// emoticonFile returns the image file for a [name] code, or "" if there is none.
+// Names with path separators are not looked up, so codes cannot reach outside Dir.
+func (in *inlineImages) emoticonFile(code string) string {
+	if file, ok := in.config.Codes[code]; ok {
+		return file
+	}
+	name := strings.TrimSuffix(strings.TrimPrefix(code, "["), "]")
+	if strings.ContainsAny(name, `/\`) {
+		return ""
+	}
+	return in.existingFile(name + ".png")
+}
+
+// emojiFile returns the image file for an emoji sequence, trying the name without
+// variation selectors as well, as emoji sets commonly omit U+FE0F.
+func (in *inlineImages) emojiFile(seq []rune) string {
+	name := func(skipVS bool) string {
+		parts := make([]string, 0, len(seq))
+		for _, r := range seq {
+			if skipVS && r == 0xFE0F {
+				continue
+			}
+			parts = append(parts, fmt.Sprintf("%x", r))
+		}
+		return strings.Join(parts, "-") + ".png"
+	}
+	if file := in.existingFile(name(false)); file != "" {
+		return file
+	}
+	return in.existingFile(name(true))
+}
+
+func (in *inlineImages) existingFile(name string) string {
+	if in.config.Dir == "" {
+		return ""
+	}
+	if _, err := os.Stat(filepath.Join(in.config.Dir, name)); err != nil {
+		return ""
+	}
+	return name
+}
+
+// emojiSequenceLength returns the number of runes of the emoji sequence at the start
+// of runes, or 0 if runes does not start with an emoji. It covers flags, keycaps,
+// skin tone modifiers, variation selectors and ZWJ sequences.
+func emojiSequenceLength(runes []rune) int {
+	if len(runes) == 0 {
+		return 0
+	}
+	// Flags are pairs of regional indicators.
+	if isRegionalIndicator(runes[0]) {
+		if len(runes) > 1 && isRegionalIndicator(runes[1]) {
+			return 2
+		}
+		return 0
+	}
+	// Keycaps: digit, # or * followed by U+FE0F U+20E3.
+	if strings.ContainsRune("0123456789#*", runes[0]) {
+		if len(runes) > 2 && runes[1] == 0xFE0F && runes[2] == 0x20E3 {
+			return 3
+		}
+		return 0
+	}
+	if !isEmojiBase(runes[0]) {
+		return 0
+	}
+	n := 1
+	for n < len(runes) {
+		switch r := runes[n]; {
+		case r == 0xFE0F || (r >= 0x1F3FB && r <= 0x1F3FF):
+			n++
+		case r == 0x200D && n+1 < len(runes) && isEmojiBase(runes[n+1]):
+			n += 2
+		default:
+			return n
+		}
+	}
+	return n
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
+
+// isEmojiBase reports whether r is in one of the main emoji blocks.
+func isEmojiBase(r rune) bool {
+	switch {
+	case r >= 0x1F300 && r <= 0x1FAFF: // pictographs, emoticons, transport, supplemental symbols
+		return true
+	case r >= 0x2600 && r <= 0x27BF: // miscellaneous symbols, dingbats
+		return true
+	case r == 0x2B50 || r == 0x2B55 || r == 0x2764 || r == 0x203C || r == 0x2049:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package waterfall
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// inlineTestConfig returns emoticon settings with images for [微笑] and 😀.
+func inlineTestConfig(t *testing.T) EmoticonConfig {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range []string{"微笑.png", "1f600.png"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := DefaultEmoticonConfig()
+	cfg.Enabled, cfg.Emoji, cfg.Dir = true, true, dir
+	return cfg
+}
+
+func TestInlineSubstituteKeepsPrivateUseCharacters(t *testing.T) {
+	in := newInlineImages(inlineTestConfig(t))
+	// U+F0000 is the first placeholder rune, so the text's own character must not be
+	// taken for [微笑].
+	text := "\U000F0000好[微笑]天气😀[未知]\U000FFFFD"
+	substituted := in.substitute(text)
+
+	var images []string
+	for _, r := range substituted {
+		if g, ok := in.glyph(r); ok {
+			images = append(images, g.code)
+		}
+	}
+	if strings.Join(images, " ") != "[微笑] 😀" {
+		t.Errorf("image glyphs %q, want [微笑] and 😀", images)
+	}
+	if got := in.expand(substituted); got != text {
+		t.Errorf("expand(substitute(%q)) = %q", text, got)
+	}
+	if r := in.source([]rune(substituted)[0]); r != 0xF0000 {
+		t.Errorf("source of the first placeholder = %U, want U+F0000", r)
+	}
+}
+
+func TestInlineRunsKeepPrivateUseCharacters(t *testing.T) {
+	cfg := DefaultLayoutConfig()
+	cfg.Emoticons = inlineTestConfig(t)
+	text := "私用区\U000F0001[微笑]"
+	e := NewContinuousLayoutEngineWithConfig([]Entry{{ID: 1, Time: "2025-03-30 17:50:00", Text: text}}, cfg)
+	e.logger = discardLogger
+	pages, err := e.ProcessEntries()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry := pages[0].Entries[0]
+	if strings.Join(entry.Texts, "") != text {
+		t.Errorf("texts %q, want %q", entry.Texts, text)
+	}
+	var runs []string
+	for _, run := range entry.InlineRuns {
+		runs = append(runs, run.Text+run.Code)
+	}
+	if want := []string{"私用区\U000F0001", "[微笑]"}; strings.Join(runs, "|") != strings.Join(want, "|") {
+		t.Errorf("inline runs %q, want %q", runs, want)
+	}
+}
+
+func TestEmojiSequenceLength(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"😀好", 1},
+		{"👍🏽!", 2},   // skin tone modifier
+		{"❤️x", 2},   // variation selector
+		{"👨‍👩‍👧", 5}, // ZWJ sequence
+		{"🇨🇳🇯🇵", 2},  // one flag
+		{"🇨", 0},     // lone regional indicator
+		{"1️⃣", 3},   // keycap
+		{"1", 0},
+		{"好", 0},
+	}
+	for _, tt := range tests {
+		if got := emojiSequenceLength([]rune(tt.text)); got != tt.want {
+			t.Errorf("emojiSequenceLength(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
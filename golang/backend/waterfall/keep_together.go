@@ -0,0 +1,124 @@
+package waterfall
+
+import "strings"
+
+// KeepTogether holds the rules that move content to the next page instead of
+// splitting it across pages. Each rule only applies when the content fits on a
+// fresh page.
+type KeepTogether struct {
+	// TimeWithContent keeps an entry's time with its first text line or first
+	// picture row, so no page ends with a bare date.
+	TimeWithContent bool `json:"time_with_content" yaml:"time_with_content"`
+	// ShortEntryHeight keeps whole the entries that are at most this tall, in pixels at
+	// 300 DPI. 0 switches the rule off.
+	ShortEntryHeight float64 `json:"short_entry_height" yaml:"short_entry_height"`
+	// PictureGroups keeps the pictures of an entry on one page.
+	PictureGroups bool `json:"picture_groups" yaml:"picture_groups"`
+}
+
+// DefaultKeepTogether returns the rules applied by default: only the time is kept
+// with the entry's first content.
+func DefaultKeepTogether() KeepTogether {
+	return KeepTogether{TimeWithContent: true}
+}
+
+// trialLayout runs place on a scratch engine whose current page is filled down to y,
+// and returns the pages it produced. keep selects the keep-together rules the scratch
+// engine applies; the rules that call trialLayout switch themselves off so trials do
+// not nest. The optimizer's choice to keep the pictures at full scale carries over,
+// its choice to keep them together is up to keep.
+func (e *ContinuousLayoutEngine) trialLayout(y float64, keep KeepTogether, place func(sim *ContinuousLayoutEngine)) []ContinuousLayoutPage {
+	sim := e.simulation(nil)
+	sim.config.KeepTogether = keep
+	if e.pictureChoice == pictureChoiceFullScale {
+		sim.pictureChoice = pictureChoiceFullScale
+	}
+	sim.newPage()
+	if y > sim.marginTop {
+		// Content above y, so spacing is added as on the real page.
+		sim.currentPage.Entries = append(sim.currentPage.Entries, PageEntry{TimeArea: [][]float64{{sim.marginLeft, y}, {sim.marginLeft, y}}})
+		sim.currentY = y
+	}
+	place(sim)
+	return sim.pages
+}
+
+// entryStartsNewPage reports whether the entry-level keep-together rules move entry
+// to the next page. The time rule checks only the time block and the entry's first
+// line or first picture row, see firstContentFits; the short entry rule lays the entry
+// out once on a fresh page.
+func (e *ContinuousLayoutEngine) entryStartsNewPage(entry Entry) bool {
+	rules := e.config.KeepTogether
+	if e.currentY <= e.marginTop {
+		return false
+	}
+	// Space for the entry below the entry spacing. Without room for the time block,
+	// the entry starts a new page anyway.
+	remaining := e.marginTop + e.availableHeight - e.currentY - e.entrySpacing
+	if remaining < e.timeHeight {
+		return false
+	}
+	nested := KeepTogether{PictureGroups: rules.PictureGroups || e.pictureChoice == pictureChoiceNewPage}
+
+	if rules.TimeWithContent && !e.firstContentFits(entry, nested) {
+		e.debugf("KeepTogether", "Time of entry %d would end the page alone. Starting a new page.", entry.ID)
+		e.traceRule("KeepTogether", "time_with_content", "The time would end the page alone. Starting a new page.")
+		return true
+	}
+
+	if limit := rules.ShortEntryHeight * e.dpiScale; limit > 0 {
+		fresh := e.trialLayout(e.marginTop, nested, func(sim *ContinuousLayoutEngine) {
+			sim.processEntry(entry, entry.ID)
+		})
+		if height := pageContentBottom(fresh[0], e.marginTop) - e.marginTop; len(fresh) == 1 && height <= limit && height > remaining {
+			e.debugf("KeepTogether", "Short entry %d would be split. Starting a new page.", entry.ID)
+			e.traceRule("KeepTogether", "short_entry_height", "The short entry would be split across pages. Starting a new page.")
+			return true
+		}
+	}
+	return false
+}
+
+// firstContentFits reports whether the first line of entry's text, or without text its
+// first picture row, fits on the current page below the entry's time block. The
+// pictures are placed on a trial engine applying keep, as the minimum heights alone
+// do not tell how tall their first row is; the text is not laid out.
+func (e *ContinuousLayoutEngine) firstContentFits(entry Entry, keep KeepTogether) bool {
+	below := e.currentY + e.entrySpacing + e.timeHeight
+	if strings.TrimSpace(entry.Text) != "" {
+		return below+e.elementSpacing+e.lineHeight <= e.marginTop+e.availableHeight
+	}
+	if len(entry.Pictures) == 0 {
+		return true
+	}
+	pages := e.trialLayout(below, keep, func(sim *ContinuousLayoutEngine) {
+		sim.processPictures(entry.Pictures)
+	})
+	first := pages[0].Entries[len(pages[0].Entries)-1]
+	return len(first.Pictures) > 0
+}
+
+// picturesStartNewPage reports whether the picture group rule, or the pagination
+// optimizer's choice for the entry, moves pictures to the next page: they would be
+// split across pages here but fit on a fresh page.
+func (e *ContinuousLayoutEngine) picturesStartNewPage(pictures []Picture) bool {
+	keep := e.config.KeepTogether.PictureGroups || e.pictureChoice == pictureChoiceNewPage
+	if !keep || e.currentY <= e.marginTop {
+		return false
+	}
+	place := func(sim *ContinuousLayoutEngine) {
+		if len(sim.currentPage.Entries) == 0 {
+			sim.currentPage.Entries = append(sim.currentPage.Entries, PageEntry{})
+		}
+		sim.processPictures(pictures)
+	}
+	if len(e.trialLayout(e.currentY, KeepTogether{}, place)) < 2 {
+		return false
+	}
+	if len(e.trialLayout(e.marginTop, KeepTogether{}, place)) > 1 {
+		return false
+	}
+	e.debugf("KeepTogether", "%d pictures would be split across pages. Starting a new page.", len(pictures))
+	e.traceRule("KeepTogether", "picture_groups", "The pictures would be split across pages. Starting a new page.")
+	return true
+}
@@ -0,0 +1,104 @@
+package waterfall
+
+import (
+	"strings"
+	"testing"
+)
+
+// keepTogetherEngine returns an engine with keep on a page filled so that space
+// pixels are left at the bottom.
+func keepTogetherEngine(keep KeepTogether, space float64) *ContinuousLayoutEngine {
+	cfg := DefaultLayoutConfig()
+	cfg.KeepTogether = keep
+	e := NewContinuousLayoutEngineWithConfig(nil, cfg)
+	e.logger = discardLogger
+	e.newPage()
+	e.currentPage.Entries = append(e.currentPage.Entries, PageEntry{ID: 1})
+	e.currentY = e.marginTop + e.availableHeight - space
+	return e
+}
+
+func TestKeepTimeWithContent(t *testing.T) {
+	text := Entry{ID: 2, Time: "2025年3月30日 18:00", Text: "今天天气很好。"}
+	pictures := Entry{ID: 3, Time: "2025年3月30日 18:00", Pictures: fallbackPictures(4, 0, 0)}
+	timeOnly := Entry{ID: 4, Time: "2025年3月30日 18:00"}
+	on := KeepTogether{TimeWithContent: true}
+
+	e := keepTogetherEngine(on, 0)
+	timeBlock := e.entrySpacing + e.timeHeight
+	firstLine := timeBlock + e.elementSpacing + e.lineHeight
+	tests := []struct {
+		name  string
+		keep  KeepTogether
+		entry Entry
+		space float64
+		want  bool
+	}{
+		{"line fits", on, text, firstLine, false},
+		{"line does not fit", on, text, firstLine - 1, true},
+		{"rule off", KeepTogether{}, text, firstLine - 1, false},
+		{"no room for the time", on, text, timeBlock - 1, false},
+		{"time only", on, timeOnly, timeBlock, false},
+		{"picture row does not fit", on, pictures, timeBlock + 100, true},
+		{"picture row fits", on, pictures, e.availableHeight / 2, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := keepTogetherEngine(tt.keep, tt.space)
+			if got := e.entryStartsNewPage(tt.entry); got != tt.want {
+				t.Errorf("entryStartsNewPage = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeepShortEntry(t *testing.T) {
+	entry := Entry{ID: 2, Time: "2025年3月30日 18:00", Text: strings.Repeat("今天天气很好，我们去公园散步。", 8)}
+
+	// The time and first line fit, the whole entry does not.
+	e := keepTogetherEngine(KeepTogether{}, 0)
+	space := e.entrySpacing + e.timeHeight + e.elementSpacing + 2*e.lineHeight
+	tests := []struct {
+		name  string
+		limit float64 // at 300 DPI
+		space float64
+		want  bool
+	}{
+		{"short entry split", 2000, space, true},
+		{"rule off", 0, space, false},
+		{"entry taller than the limit", 100, space, false},
+		{"entry fits", 2000, e.availableHeight, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := keepTogetherEngine(KeepTogether{ShortEntryHeight: tt.limit}, tt.space)
+			if got := e.entryStartsNewPage(entry); got != tt.want {
+				t.Errorf("entryStartsNewPage = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeepPictureGroups(t *testing.T) {
+	pictures := fallbackPictures(4, 0, 0)
+	tests := []struct {
+		name  string
+		keep  bool
+		space float64 // share of the printable height
+		want  bool
+	}{
+		{"pictures split", true, 0.3, true},
+		{"rule off", false, 0.3, false},
+		{"pictures fit", true, 0.95, false},
+		{"top of the page", true, 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := keepTogetherEngine(KeepTogether{PictureGroups: tt.keep}, 0)
+			e.currentY = e.marginTop + e.availableHeight*(1-tt.space)
+			if got := e.picturesStartNewPage(pictures); got != tt.want {
+				t.Errorf("picturesStartNewPage = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
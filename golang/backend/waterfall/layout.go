@@ -0,0 +1,26 @@
+package waterfall
+
+// Layout validates cfg and lays out entries as a continuous run of pages.
+// It is the entry point for callers that do not need to drive the engine directly.
+func Layout(entries []Entry, cfg LayoutConfig) ([]ContinuousLayoutPage, error) {
+	return LayoutFromPage(entries, cfg, 1)
+}
+
+// LayoutFromPage is like Layout but numbers the pages starting at firstPage, so that
+// left/right sides and spread margins match the pages' position in the book.
+func LayoutFromPage(entries []Entry, cfg LayoutConfig, firstPage int) ([]ContinuousLayoutPage, error) {
+	pages, _, err := LayoutWithTrace(entries, cfg, firstPage)
+	return pages, err
+}
+
+// LayoutWithTrace is like LayoutFromPage and also returns the decisions recorded when
+// cfg.Trace is set, or nil otherwise.
+func LayoutWithTrace(entries []Entry, cfg LayoutConfig, firstPage int) ([]ContinuousLayoutPage, *LayoutTrace, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, err
+	}
+	engine := NewContinuousLayoutEngineWithConfig(entries, cfg)
+	engine.SetFirstPageNumber(firstPage)
+	pages, err := engine.ProcessEntries()
+	return pages, engine.Trace(), err
+}
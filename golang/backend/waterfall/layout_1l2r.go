@@ -3,7 +3,7 @@ package waterfall
 import "fmt"
 
 // --- Helper function for 1 Left, 2 Right Stacked Template ---
-func (e *ContinuousLayoutEngine) calculateLayout_1L2R(ARs []float64, types []string, AW, spacing float64) (TemplateLayout, bool, error) {
+func calculateLayout_1L2R(ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
 	// Added definition based on layout_3_pics.go version
 	layout := TemplateLayout{
 		Positions:  make([][]float64, 3),
@@ -22,11 +22,11 @@ func (e *ContinuousLayoutEngine) calculateLayout_1L2R(ARs []float64, types []str
 		WR = (AW - spacing*(1.0+AR0)) / denominator
 	}
 	if WR <= 1e-6 || WR > AW-spacing+1e-6 {
-		return layout, false, fmt.Errorf("1L2R geometry infeasible (WR=%.2f)", WR)
+		return layout, fmt.Errorf("1L2R geometry infeasible (WR=%.2f)", WR)
 	}
 	W0 := AW - spacing - WR
 	if W0 <= 1e-6 {
-		return layout, false, fmt.Errorf("1L2R geometry infeasible (W0=%.2f)", W0)
+		return layout, fmt.Errorf("1L2R geometry infeasible (W0=%.2f)", W0)
 	}
 	H0 := 0.0
 	if AR0 > 1e-6 {
@@ -41,7 +41,7 @@ func (e *ContinuousLayoutEngine) calculateLayout_1L2R(ARs []float64, types []str
 		H2 = WR / ARs[2]
 	}
 	if H0 <= 1e-6 || H1 <= 1e-6 || H2 <= 1e-6 {
-		return layout, false, fmt.Errorf("1L2R calculated zero height")
+		return layout, fmt.Errorf("1L2R calculated zero height")
 	}
 
 	layout.TotalHeight = H0
@@ -53,21 +53,5 @@ func (e *ContinuousLayoutEngine) calculateLayout_1L2R(ARs []float64, types []str
 	layout.Positions[2] = []float64{W0 + spacing, H1 + spacing}
 	layout.Dimensions[2] = []float64{WR, H2}
 
-	// Type-specific minimum height check
-	meetsMin := true
-	heights := []float64{H0, H1, H2} // Heights for pics 0, 1, 2
-	requiredMinHeights := make([]float64, 3)
-	for i, picType := range types {
-		// Use the GetRequiredMinHeight helper function
-		requiredMinHeight := GetRequiredMinHeight(e, picType, 3)
-		requiredMinHeights[i] = requiredMinHeight // Store it (might be redundant)
-
-		if heights[i] < requiredMinHeights[i] {
-			meetsMin = false
-			break
-		}
-	}
-	// No explicit min width check based on rules
-
-	return layout, meetsMin, nil
+	return layout, nil
 }
@@ -3,7 +3,7 @@ package waterfall
 import "fmt"
 
 // --- Helper function for 1 Top, 2 Bottom Stacked Template ---
-func (e *ContinuousLayoutEngine) calculateLayout_1T2B(ARs []float64, types []string, AW, spacing float64) (TemplateLayout, bool, error) {
+func calculateLayout_1T2B(ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
 	layout := TemplateLayout{
 		Positions:  make([][]float64, 3),
 		Dimensions: make([][]float64, 3),
@@ -13,14 +13,14 @@ func (e *ContinuousLayoutEngine) calculateLayout_1T2B(ARs []float64, types []str
 	// Pic 0 takes full width
 	W0 := AW
 	if W0 <= 1e-6 {
-		return layout, false, fmt.Errorf("1T2B available width is zero")
+		return layout, fmt.Errorf("1T2B available width is zero")
 	}
 	H0 := 0.0
 	if AR0 > 1e-6 {
 		H0 = W0 / AR0
 	}
 	if H0 <= 1e-6 {
-		return layout, false, fmt.Errorf("1T2B calculated zero height for top picture")
+		return layout, fmt.Errorf("1T2B calculated zero height for top picture")
 	}
 
 	// Calculate bottom row height based on fitting pics 1 & 2 in available width
@@ -30,10 +30,10 @@ func (e *ContinuousLayoutEngine) calculateLayout_1T2B(ARs []float64, types []str
 	if bottomRowAvailableWidth > 1e-6 && bottomTotalARSum > 1e-6 {
 		H_bottom = bottomRowAvailableWidth / bottomTotalARSum
 	} else {
-		return layout, false, fmt.Errorf("cannot calculate 1T2B bottom row height")
+		return layout, fmt.Errorf("cannot calculate 1T2B bottom row height")
 	}
 	if H_bottom <= 1e-6 {
-		return layout, false, fmt.Errorf("1T2B calculated zero height for bottom row")
+		return layout, fmt.Errorf("1T2B calculated zero height for bottom row")
 	}
 
 	W1 := H_bottom * AR1
@@ -51,40 +51,5 @@ func (e *ContinuousLayoutEngine) calculateLayout_1T2B(ARs []float64, types []str
 	layout.Positions[2] = []float64{W1 + spacing, H0 + spacing} // Pic 2 Bottom Right
 	layout.Dimensions[2] = []float64{W2, H_bottom}
 
-	// Type-specific minimum height check
-	meetsMin := true
-	// heights := []float64{H0, H_bottom, H_bottom} // Heights for pics 0, 1, 2 respectively - Not strictly needed for check logic
-	requiredMinHeights := make([]float64, 3)
-	for i, picType := range types {
-		// Use the GetRequiredMinHeight helper function
-		requiredMinHeight := GetRequiredMinHeight(e, picType, 3)
-		requiredMinHeights[i] = requiredMinHeight // Store it (might be redundant)
-
-		/* // Old switch logic removed
-		switch picType {
-		case "wide":
-			requiredMinHeights[i] = e.minWideHeight
-		case "tall":
-			requiredMinHeights[i] = e.minTallHeight
-		case "landscape":
-			requiredMinHeights[i] = e.minLandscapeHeight
-		case "portrait":
-			requiredMinHeights[i] = e.minPortraitHeight
-		default:
-			requiredMinHeights[i] = e.minLandscapeHeight // Fallback
-		}
-		*/
-		// Use correct height for check (H0 for pic 0, H_bottom for pics 1 & 2)
-		checkHeight := H0
-		if i > 0 {
-			checkHeight = H_bottom
-		}
-		if checkHeight < requiredMinHeights[i] {
-			meetsMin = false
-			break
-		}
-	}
-	// No explicit min width check based on rules
-
-	return layout, meetsMin, nil
+	return layout, nil
 }
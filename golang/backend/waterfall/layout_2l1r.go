@@ -3,7 +3,7 @@ package waterfall
 import "fmt"
 
 // --- Helper function for 2 Left Stacked, 1 Right Template --- (Mirror of 1L2R)
-func (e *ContinuousLayoutEngine) calculateLayout_2L1R(ARs []float64, types []string, AW, spacing float64) (TemplateLayout, bool, error) {
+func calculateLayout_2L1R(ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
 	layout := TemplateLayout{
 		Positions:  make([][]float64, 3),
 		Dimensions: make([][]float64, 3),
@@ -25,12 +25,12 @@ func (e *ContinuousLayoutEngine) calculateLayout_2L1R(ARs []float64, types []str
 	}
 
 	if WL <= 1e-6 || WL > AW-spacing+1e-6 {
-		return layout, false, fmt.Errorf("2L1R geometry infeasible (WL=%.2f)", WL)
+		return layout, fmt.Errorf("2L1R geometry infeasible (WL=%.2f)", WL)
 	}
 
 	W2 := AW - spacing - WL
 	if W2 <= 1e-6 {
-		return layout, false, fmt.Errorf("2L1R geometry infeasible (W2=%.2f)", W2)
+		return layout, fmt.Errorf("2L1R geometry infeasible (W2=%.2f)", W2)
 	}
 
 	H0 := 0.0
@@ -47,7 +47,7 @@ func (e *ContinuousLayoutEngine) calculateLayout_2L1R(ARs []float64, types []str
 	}
 
 	if H0 <= 1e-6 || H1 <= 1e-6 || H2 <= 1e-6 {
-		return layout, false, fmt.Errorf("2L1R calculated zero height")
+		return layout, fmt.Errorf("2L1R calculated zero height")
 	}
 
 	layout.TotalHeight = H2
@@ -59,21 +59,5 @@ func (e *ContinuousLayoutEngine) calculateLayout_2L1R(ARs []float64, types []str
 	layout.Positions[2] = []float64{WL + spacing, 0}
 	layout.Dimensions[2] = []float64{W2, H2}
 
-	// Type-specific minimum height check
-	meetsMin := true
-	heights := []float64{H0, H1, H2} // Heights for pics 0, 1, 2
-	requiredMinHeights := make([]float64, 3)
-	for i, picType := range types {
-		// Use the GetRequiredMinHeight helper function
-		requiredMinHeight := GetRequiredMinHeight(e, picType, 3)
-		requiredMinHeights[i] = requiredMinHeight // Store it (might be redundant)
-
-		if heights[i] < requiredMinHeights[i] {
-			meetsMin = false
-			break
-		}
-	}
-	// No explicit min width check based on rules
-
-	return layout, meetsMin, nil
+	return layout, nil
 }
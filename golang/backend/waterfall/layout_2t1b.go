@@ -3,7 +3,7 @@ package waterfall
 import "fmt"
 
 // --- Helper function for 2 Top, 1 Bottom Full Width Template ---
-func (e *ContinuousLayoutEngine) calculateLayout_2T1B(ARs []float64, types []string, AW, spacing float64) (TemplateLayout, bool, error) {
+func calculateLayout_2T1B(ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
 	layout := TemplateLayout{
 		Positions:  make([][]float64, 3),
 		Dimensions: make([][]float64, 3),
@@ -17,10 +17,10 @@ func (e *ContinuousLayoutEngine) calculateLayout_2T1B(ARs []float64, types []str
 	if topRowAvailableWidth > 1e-6 && topTotalARSum > 1e-6 {
 		H_top = topRowAvailableWidth / topTotalARSum
 	} else {
-		return layout, false, fmt.Errorf("cannot calculate 2T1B top row height")
+		return layout, fmt.Errorf("cannot calculate 2T1B top row height")
 	}
 	if H_top <= 1e-6 {
-		return layout, false, fmt.Errorf("2T1B calculated zero height for top row")
+		return layout, fmt.Errorf("2T1B calculated zero height for top row")
 	}
 
 	W0 := H_top * AR0
@@ -33,7 +33,7 @@ func (e *ContinuousLayoutEngine) calculateLayout_2T1B(ARs []float64, types []str
 		H2 = W2 / AR2
 	}
 	if H2 <= 1e-6 {
-		return layout, false, fmt.Errorf("2T1B calculated zero height for bottom picture")
+		return layout, fmt.Errorf("2T1B calculated zero height for bottom picture")
 	}
 
 	layout.TotalHeight = H_top + spacing + H2
@@ -46,41 +46,5 @@ func (e *ContinuousLayoutEngine) calculateLayout_2T1B(ARs []float64, types []str
 	layout.Positions[2] = []float64{0, H_top + spacing} // Pic 2 Bottom Full
 	layout.Dimensions[2] = []float64{W2, H2}
 
-	// Type-specific minimum height check
-	meetsMin := true
-	// heights := []float64{H_top, H_top, H2} // Heights for pics 0, 1, 2 - Not strictly needed
-	requiredMinHeights := make([]float64, 3)
-	for i, picType := range types {
-		// Use the GetRequiredMinHeight helper function
-		requiredMinHeight := GetRequiredMinHeight(e, picType, 3)
-		requiredMinHeights[i] = requiredMinHeight // Store it (might be redundant)
-
-		/* // Old switch logic removed
-		switch picType {
-		case "wide":
-			requiredMinHeights[i] = e.minWideHeight
-		case "tall":
-			requiredMinHeights[i] = e.minTallHeight
-		case "landscape":
-			requiredMinHeights[i] = e.minLandscapeHeight
-		case "portrait":
-			requiredMinHeights[i] = e.minPortraitHeight
-		default:
-			requiredMinHeights[i] = e.minLandscapeHeight // Fallback
-		}
-		*/
-
-		// Use correct height (H_top for 0, 1; H2 for 2)
-		checkHeight := H_top
-		if i == 2 {
-			checkHeight = H2
-		}
-		if checkHeight < requiredMinHeights[i] {
-			meetsMin = false
-			break
-		}
-	}
-	// No explicit min width check based on rules
-
-	return layout, meetsMin, nil
+	return layout, nil
 }
@@ -3,7 +3,7 @@ package waterfall
 import "fmt"
 
 // --- Helper function for 3 in a Row Template ---
-func (e *ContinuousLayoutEngine) calculateLayout_3Row(ARs []float64, types []string, AW, spacing float64) (TemplateLayout, bool, error) {
+func calculateLayout_3Row(ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
 	layout := TemplateLayout{
 		Positions:  make([][]float64, 3),
 		Dimensions: make([][]float64, 3),
@@ -16,10 +16,10 @@ func (e *ContinuousLayoutEngine) calculateLayout_3Row(ARs []float64, types []str
 	if rowAvailableWidth > 1e-6 && totalARSum > 1e-6 {
 		H = rowAvailableWidth / totalARSum
 	} else {
-		return layout, false, fmt.Errorf("cannot calculate 3-in-a-row layout (zero width or AR sum)")
+		return layout, fmt.Errorf("cannot calculate 3-in-a-row layout (zero width or AR sum)")
 	}
 	if H <= 1e-6 {
-		return layout, false, fmt.Errorf("3-in-a-row calculated zero height")
+		return layout, fmt.Errorf("3-in-a-row calculated zero height")
 	}
 
 	W0 := H * AR0
@@ -38,35 +38,5 @@ func (e *ContinuousLayoutEngine) calculateLayout_3Row(ARs []float64, types []str
 		}
 	}
 
-	// Type-specific minimum height check
-	meetsMin := true
-	requiredMinHeights := make([]float64, 3)
-	for i, picType := range types {
-		// Use the GetRequiredMinHeight helper function
-		requiredMinHeight := GetRequiredMinHeight(e, picType, 3)
-		requiredMinHeights[i] = requiredMinHeight // Store it (might be redundant)
-
-		/* // Old switch logic removed
-		switch picType {
-		case "wide":
-			requiredMinHeights[i] = e.minWideHeight
-		case "tall":
-			requiredMinHeights[i] = e.minTallHeight
-		case "landscape":
-			requiredMinHeights[i] = e.minLandscapeHeight
-		case "portrait":
-			requiredMinHeights[i] = e.minPortraitHeight
-		default:
-			requiredMinHeights[i] = e.minLandscapeHeight // Fallback
-		}
-		*/
-		// All pictures have the same height H in this layout
-		if H < requiredMinHeights[i] {
-			meetsMin = false
-			break
-		}
-	}
-	// No explicit min width check based on rules
-
-	return layout, meetsMin, nil
+	return layout, nil
 }
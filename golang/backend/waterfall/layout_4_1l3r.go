@@ -14,7 +14,7 @@ import (
 // Check if W0 + spacing + WR = AW.
 // This often requires iterative solving or algebraic manipulation.
 // Simpler approach: Treat right side as a single column, calculate its total AR.
-func calculateLayout_4_1L3R(e *ContinuousLayoutEngine, ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
+func calculateLayout_4_1L3R(ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
 	layout := TemplateLayout{Positions: make([][]float64, 4), Dimensions: make([][]float64, 4)}
 	if len(ARs) != 4 || len(types) != 4 {
 		return layout, fmt.Errorf("1L3R layout requires 4 ARs and types")
@@ -112,7 +112,7 @@ func calculateLayout_4_1L3R(e *ContinuousLayoutEngine, ARs []float64, types []st
 	// Verify calculated height matches estimate (within tolerance)
 	rightStackH := H1 + H2 + H3 + 2*spacing
 	if math.Abs(rightStackH-H) > 1e-3 {
-		fmt.Printf("Warning: 1L3R height mismatch H=%.2f, H_stack=%.2f. Adjusting.\n", H, rightStackH)
+		warnf("calculateLayout_4_1L3R", "1L3R height mismatch H=%.2f, H_stack=%.2f. Adjusting.", H, rightStackH)
 		// Could potentially adjust spacing or rescale, but let's proceed with calculated H for now.
 		H = rightStackH // Favor the stack height calculation? Or average?
 		W0 = H * AR0
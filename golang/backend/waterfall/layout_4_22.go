@@ -4,7 +4,7 @@ import "fmt"
 
 // calculateLayout_4_2x2 calculates the 2x2 grid layout.
 // Aims for uniform height within each row.
-func calculateLayout_4_2x2(e *ContinuousLayoutEngine, ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
+func calculateLayout_4_2x2(ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
 	layout := TemplateLayout{Positions: make([][]float64, 4), Dimensions: make([][]float64, 4)}
 	if len(ARs) != 4 || len(types) != 4 {
 		return layout, fmt.Errorf("2x2 layout requires 4 ARs and types")
@@ -7,7 +7,7 @@ import (
 
 // calculateLayout_4_3L1R calculates the 3 Left Stacked, 1 Right layout.
 // Mirror image of 1L3R.
-func calculateLayout_4_3L1R(e *ContinuousLayoutEngine, ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
+func calculateLayout_4_3L1R(ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
 	layout := TemplateLayout{Positions: make([][]float64, 4), Dimensions: make([][]float64, 4)}
 	if len(ARs) != 4 || len(types) != 4 {
 		return layout, fmt.Errorf("3L1R layout requires 4 ARs and types")
@@ -82,7 +82,7 @@ func calculateLayout_4_3L1R(e *ContinuousLayoutEngine, ARs []float64, types []st
 	// Verify calculated height matches estimate (within tolerance)
 	leftStackH := H0 + H1 + H2 + 2*spacing
 	if math.Abs(leftStackH-H) > 1e-3 {
-		fmt.Printf("Warning: 3L1R height mismatch H=%.2f, H_stack=%.2f. Adjusting.\n", H, leftStackH)
+		warnf("calculateLayout_4_3L1R", "3L1R height mismatch H=%.2f, H_stack=%.2f. Adjusting.", H, leftStackH)
 		H = leftStackH // Favor the stack height calculation
 		W3 = H * AR3
 	}
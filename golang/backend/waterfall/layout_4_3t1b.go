@@ -3,7 +3,7 @@ package waterfall
 import "fmt"
 
 // calculateLayout_4_3T1B calculates the 3 Top, 1 Bottom layout.
-func calculateLayout_4_3T1B(e *ContinuousLayoutEngine, ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
+func calculateLayout_4_3T1B(ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
 	layout := TemplateLayout{Positions: make([][]float64, 4), Dimensions: make([][]float64, 4)}
 	if len(ARs) != 4 || len(types) != 4 {
 		return layout, fmt.Errorf("3T1B layout requires 4 ARs and types")
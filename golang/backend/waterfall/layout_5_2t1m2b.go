@@ -3,7 +3,7 @@ package waterfall
 import "fmt"
 
 // calculateLayout_5_2T1M2B calculates the 2 Top, 1 Middle, 2 Bottom layout.
-func calculateLayout_5_2T1M2B(e *ContinuousLayoutEngine, ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
+func calculateLayout_5_2T1M2B(ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
 	layout := TemplateLayout{Positions: make([][]float64, 5), Dimensions: make([][]float64, 5)}
 	if len(ARs) != 5 || len(types) != 5 {
 		return layout, fmt.Errorf("2T1M2B layout requires 5 ARs and types")
@@ -3,7 +3,7 @@ package waterfall
 import "fmt"
 
 // calculateLayout_5_2T2M1B calculates the 2 Top, 2 Middle, 1 Bottom layout.
-func calculateLayout_5_2T2M1B(e *ContinuousLayoutEngine, ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
+func calculateLayout_5_2T2M1B(ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
 	layout := TemplateLayout{Positions: make([][]float64, 5), Dimensions: make([][]float64, 5)}
 	if len(ARs) != 5 || len(types) != 5 {
 		return layout, fmt.Errorf("2T2M1B layout requires 5 ARs and types")
@@ -3,7 +3,7 @@ package waterfall
 import "fmt"
 
 // calculateLayout_6_1T2M3B calculates the 1 Top, 2 Middle, 3 Bottom layout.
-func calculateLayout_6_1T2M3B(e *ContinuousLayoutEngine, ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
+func calculateLayout_6_1T2M3B(ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
 	layout := TemplateLayout{Positions: make([][]float64, 6), Dimensions: make([][]float64, 6)}
 	if len(ARs) != 6 || len(types) != 6 {
 		return layout, fmt.Errorf("1T2M3B layout requires 6 ARs and types")
@@ -6,7 +6,7 @@ import (
 )
 
 // calculateLayout_6_3L3R calculates the 3 Left, 3 Right Stacked layout.
-func calculateLayout_6_3L3R(e *ContinuousLayoutEngine, ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
+func calculateLayout_6_3L3R(ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
 	layout := TemplateLayout{Positions: make([][]float64, 6), Dimensions: make([][]float64, 6)}
 	if len(ARs) != 6 || len(types) != 6 {
 		return layout, fmt.Errorf("3L3R layout requires 6 ARs and types")
@@ -76,7 +76,7 @@ func calculateLayout_6_3L3R(e *ContinuousLayoutEngine, ARs []float64, types []st
 	leftStackH := H0 + H1 + H2 + 2*spacing
 	rightStackH := H3 + H4 + H5 + 2*spacing
 	if math.Abs(leftStackH-H) > 1e-3 || math.Abs(rightStackH-H) > 1e-3 {
-		fmt.Printf("Warning: 3L3R height mismatch H=%.2f, HL=%.2f, HR=%.2f. Using calculated H.\n", H, leftStackH, rightStackH)
+		warnf("calculateLayout_6_3L3R", "3L3R height mismatch H=%.2f, HL=%.2f, HR=%.2f. Using calculated H.", H, leftStackH, rightStackH)
 		// Don't adjust H here, stick with the geometrically derived total H
 	}
 
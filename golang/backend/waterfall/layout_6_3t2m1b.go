@@ -3,7 +3,7 @@ package waterfall
 import "fmt"
 
 // calculateLayout_6_3T2M1B calculates the 3 Top, 2 Middle, 1 Bottom layout.
-func calculateLayout_6_3T2M1B(e *ContinuousLayoutEngine, ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
+func calculateLayout_6_3T2M1B(ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
 	layout := TemplateLayout{Positions: make([][]float64, 6), Dimensions: make([][]float64, 6)}
 	if len(ARs) != 6 || len(types) != 6 {
 		return layout, fmt.Errorf("3T2M1B layout requires 6 ARs and types")
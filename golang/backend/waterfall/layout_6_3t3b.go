@@ -3,7 +3,7 @@ package waterfall
 import "fmt"
 
 // calculateLayout_6_3T3B calculates the 3 Top, 3 Bottom layout.
-func calculateLayout_6_3T3B(e *ContinuousLayoutEngine, ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
+func calculateLayout_6_3T3B(ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
 	layout := TemplateLayout{Positions: make([][]float64, 6), Dimensions: make([][]float64, 6)}
 	if len(ARs) != 6 || len(types) != 6 {
 		return layout, fmt.Errorf("3T3B layout requires 6 ARs and types")
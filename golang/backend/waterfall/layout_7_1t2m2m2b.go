@@ -3,7 +3,7 @@ package waterfall
 import "fmt"
 
 // calculateLayout_7_1T2M2M2B calculates the 1T-2M-2M-2B layout.
-func calculateLayout_7_1T2M2M2B(e *ContinuousLayoutEngine, ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
+func calculateLayout_7_1T2M2M2B(ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
 	layout := TemplateLayout{Positions: make([][]float64, 7), Dimensions: make([][]float64, 7)}
 	if len(ARs) != 7 || len(types) != 7 {
 		return layout, fmt.Errorf("1T2M2M2B layout requires 7 ARs and types")
@@ -3,7 +3,7 @@ package waterfall
 import "fmt"
 
 // calculateLayout_7_1T2M3M1B calculates the 1T-2M-3M-1B layout.
-func calculateLayout_7_1T2M3M1B(e *ContinuousLayoutEngine, ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
+func calculateLayout_7_1T2M3M1B(ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
 	layout := TemplateLayout{Positions: make([][]float64, 7), Dimensions: make([][]float64, 7)}
 	if len(ARs) != 7 || len(types) != 7 {
 		return layout, fmt.Errorf("1T2M3M1B layout requires 7 ARs and types")
@@ -3,7 +3,7 @@ package waterfall
 import "fmt"
 
 // calculateLayout_7_1T3M3B calculates the 1 Top, 3 Middle, 3 Bottom layout.
-func calculateLayout_7_1T3M3B(e *ContinuousLayoutEngine, ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
+func calculateLayout_7_1T3M3B(ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
 	layout := TemplateLayout{Positions: make([][]float64, 7), Dimensions: make([][]float64, 7)}
 	if len(ARs) != 7 || len(types) != 7 {
 		return layout, fmt.Errorf("1T3M3B layout requires 7 ARs and types")
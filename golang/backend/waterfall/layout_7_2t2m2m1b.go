@@ -3,7 +3,7 @@ package waterfall
 import "fmt"
 
 // calculateLayout_7_2T2M2M1B calculates the 2T-2M-2M-1B layout.
-func calculateLayout_7_2T2M2M1B(e *ContinuousLayoutEngine, ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
+func calculateLayout_7_2T2M2M1B(ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
 	layout := TemplateLayout{Positions: make([][]float64, 7), Dimensions: make([][]float64, 7)}
 	if len(ARs) != 7 || len(types) != 7 {
 		return layout, fmt.Errorf("2T2M2M1B layout requires 7 ARs and types")
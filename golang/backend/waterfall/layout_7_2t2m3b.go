@@ -3,7 +3,7 @@ package waterfall
 import "fmt"
 
 // calculateLayout_7_2T2M3B calculates the 2 Top, 2 Middle, 3 Bottom layout.
-func calculateLayout_7_2T2M3B(e *ContinuousLayoutEngine, ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
+func calculateLayout_7_2T2M3B(ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
 	layout := TemplateLayout{Positions: make([][]float64, 7), Dimensions: make([][]float64, 7)}
 	if len(ARs) != 7 || len(types) != 7 {
 		return layout, fmt.Errorf("2T2M3B layout requires 7 ARs and types")
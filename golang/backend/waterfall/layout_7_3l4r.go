@@ -3,7 +3,7 @@ package waterfall
 import "fmt"
 
 // calculateLayout_7_3L4R calculates the 3 Left, 4 Right Stacked layout.
-func calculateLayout_7_3L4R(e *ContinuousLayoutEngine, ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
+func calculateLayout_7_3L4R(ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
 	layout := TemplateLayout{Positions: make([][]float64, 7), Dimensions: make([][]float64, 7)}
 	if len(ARs) != 7 || len(types) != 7 {
 		return layout, fmt.Errorf("3L4R layout requires 7 ARs and types")
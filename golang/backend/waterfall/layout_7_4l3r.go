@@ -3,7 +3,7 @@ package waterfall
 import "fmt"
 
 // calculateLayout_7_4L3R calculates the 4 Left, 3 Right Stacked layout.
-func calculateLayout_7_4L3R(e *ContinuousLayoutEngine, ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
+func calculateLayout_7_4L3R(ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
 	layout := TemplateLayout{Positions: make([][]float64, 7), Dimensions: make([][]float64, 7)}
 	if len(ARs) != 7 || len(types) != 7 {
 		return layout, fmt.Errorf("4L3R layout requires 7 ARs and types")
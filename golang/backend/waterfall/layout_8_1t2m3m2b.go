@@ -3,7 +3,7 @@ package waterfall
 import "fmt"
 
 // calculateLayout_8_1T2M3M2B: 1 Top, 2 Mid1, 3 Mid2, 2 Bottom
-func calculateLayout_8_1T2M3M2B(e *ContinuousLayoutEngine, ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
+func calculateLayout_8_1T2M3M2B(ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
 	layout := TemplateLayout{Positions: make([][]float64, 8), Dimensions: make([][]float64, 8)}
 	if len(ARs) != 8 || len(types) != 8 {
 		return layout, fmt.Errorf("1T2M3M2B layout requires 8 ARs/types")
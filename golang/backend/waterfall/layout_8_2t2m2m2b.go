@@ -3,7 +3,7 @@ package waterfall
 import "fmt"
 
 // calculateLayout_8_2T2M2M2B: 2 Top, 2 Mid1, 2 Mid2, 2 Bottom
-func calculateLayout_8_2T2M2M2B(e *ContinuousLayoutEngine, ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
+func calculateLayout_8_2T2M2M2B(ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
 	layout := TemplateLayout{Positions: make([][]float64, 8), Dimensions: make([][]float64, 8)}
 	if len(ARs) != 8 || len(types) != 8 {
 		return layout, fmt.Errorf("2T2M2M2B layout requires 8 ARs/types")
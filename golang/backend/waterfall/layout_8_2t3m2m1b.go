@@ -3,7 +3,7 @@ package waterfall
 import "fmt"
 
 // calculateLayout_8_2T3M2M1B: 2 Top, 3 Mid1, 2 Mid2, 1 Bottom
-func calculateLayout_8_2T3M2M1B(e *ContinuousLayoutEngine, ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
+func calculateLayout_8_2T3M2M1B(ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
 	layout := TemplateLayout{Positions: make([][]float64, 8), Dimensions: make([][]float64, 8)}
 	if len(ARs) != 8 || len(types) != 8 {
 		return layout, fmt.Errorf("2T3M2M1B layout requires 8 ARs/types")
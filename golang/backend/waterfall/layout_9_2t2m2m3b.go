@@ -3,7 +3,7 @@ package waterfall
 import "fmt"
 
 // calculateLayout_9_2T2M2M3B: 2 Top, 2 Mid1, 2 Mid2, 3 Bottom
-func calculateLayout_9_2T2M2M3B(e *ContinuousLayoutEngine, ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
+func calculateLayout_9_2T2M2M3B(ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
 	layout := TemplateLayout{Positions: make([][]float64, 9), Dimensions: make([][]float64, 9)}
 	if len(ARs) != 9 || len(types) != 9 {
 		return layout, fmt.Errorf("2T2M2M3B requires 9 ARs/types")
@@ -3,7 +3,7 @@ package waterfall
 import "fmt"
 
 // calculateLayout_9_3T2M2M2B: 3 Top, 2 Mid1, 2 Mid2, 2 Bottom
-func calculateLayout_9_3T2M2M2B(e *ContinuousLayoutEngine, ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
+func calculateLayout_9_3T2M2M2B(ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
 	layout := TemplateLayout{Positions: make([][]float64, 9), Dimensions: make([][]float64, 9)}
 	if len(ARs) != 9 || len(types) != 9 {
 		return layout, fmt.Errorf("3T2M2M2B requires 9 ARs/types")
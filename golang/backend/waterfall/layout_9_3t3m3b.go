@@ -3,7 +3,7 @@ package waterfall
 import "fmt"
 
 // calculateLayout_9_3T3M3B: 3 Top, 3 Middle, 3 Bottom (3x3 Grid)
-func calculateLayout_9_3T3M3B(e *ContinuousLayoutEngine, ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
+func calculateLayout_9_3T3M3B(ARs []float64, types []string, AW, spacing float64) (TemplateLayout, error) {
 	layout := TemplateLayout{Positions: make([][]float64, 9), Dimensions: make([][]float64, 9)}
 	if len(ARs) != 9 || len(types) != 9 {
 		return layout, fmt.Errorf("3T3M3B requires 9 ARs/types")
@@ -0,0 +1,272 @@
+package waterfall
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Built-in scorer names.
+const (
+	ScorerArea         = "area"          // 图片总面积占可用区域的比例
+	ScorerUniformity   = "uniformity"    // 图片大小是否均匀
+	ScorerWhitespace   = "whitespace"    // 布局块内图片覆盖率（留白越少越高）
+	ScorerReadingOrder = "reading_order" // 图片是否按从左到右、从上到下的顺序排列
+	ScorerWeighted     = "weighted"      // 以上评分按 scorer_weights 加权
+)
+
+// LayoutCandidate is a template layout that passed the minimum height check,
+// scaled to the available height.
+type LayoutCandidate struct {
+	Template        string
+	Layout          TemplateLayout
+	Scale           float64 // factor the template was scaled down by, 1 if it fit
+	Cropped         bool    // pictures were cropped within the crop budget
+	AvailableWidth  float64
+	AvailableHeight float64
+}
+
+// LayoutScorer rates template layouts; the candidate with the highest score is chosen.
+// Built-in scorers return values between 0 and 1.
+type LayoutScorer interface {
+	Name() string
+	Score(c LayoutCandidate) float64
+}
+
+// ScorerFunc adapts a function to LayoutScorer.
+type ScorerFunc struct {
+	ScorerName string
+	Func       func(c LayoutCandidate) float64
+}
+
+// Name implements LayoutScorer.
+func (s ScorerFunc) Name() string { return s.ScorerName }
+
+// Score implements LayoutScorer.
+func (s ScorerFunc) Score(c LayoutCandidate) float64 { return s.Func(c) }
+
+// builtinScorers are the scorers that can be named in the config, apart from ScorerWeighted.
+var builtinScorers = map[string]LayoutScorer{
+	ScorerArea:         ScorerFunc{ScorerArea, scoreArea},
+	ScorerUniformity:   ScorerFunc{ScorerUniformity, scoreUniformity},
+	ScorerWhitespace:   ScorerFunc{ScorerWhitespace, scoreWhitespace},
+	ScorerReadingOrder: ScorerFunc{ScorerReadingOrder, scoreReadingOrder},
+}
+
+// LayoutScorers returns the names of the scorers that can be selected in the config.
+func LayoutScorers() []string {
+	names := []string{ScorerWeighted}
+	for name := range builtinScorers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WeightedScorer combines scorers into their weighted mean.
+type WeightedScorer struct {
+	Scorers []LayoutScorer
+	Weights []float64
+}
+
+// Name implements LayoutScorer.
+func (w WeightedScorer) Name() string { return ScorerWeighted }
+
+// Score implements LayoutScorer.
+func (w WeightedScorer) Score(c LayoutCandidate) float64 {
+	total, weights := 0.0, 0.0
+	for i, s := range w.Scorers {
+		total += w.Weights[i] * s.Score(c)
+		weights += w.Weights[i]
+	}
+	if weights == 0 {
+		return 0
+	}
+	return total / weights
+}
+
+// layoutScorer returns the scorer selected by the config.
+func (c LayoutConfig) layoutScorer() (LayoutScorer, error) {
+	if c.Scorer != ScorerWeighted {
+		s, ok := builtinScorers[c.Scorer]
+		if !ok {
+			return nil, fmt.Errorf("unknown scorer %q (available: %s)", c.Scorer, strings.Join(LayoutScorers(), ", "))
+		}
+		return s, nil
+	}
+
+	names := make([]string, 0, len(c.ScorerWeights))
+	for name := range c.ScorerWeights {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var weighted WeightedScorer
+	positive := false
+	for _, name := range names {
+		s, ok := builtinScorers[name]
+		if !ok {
+			return nil, fmt.Errorf("scorer_weights: unknown scorer %q", name)
+		}
+		weight := c.ScorerWeights[name]
+		if weight < 0 {
+			return nil, fmt.Errorf("scorer_weights: weight of %s must not be negative (got %.2f)", name, weight)
+		}
+		positive = positive || weight > 0
+		weighted.Scorers = append(weighted.Scorers, s)
+		weighted.Weights = append(weighted.Weights, weight)
+	}
+	if !positive {
+		return nil, fmt.Errorf("scorer_weights needs a positive weight for the weighted scorer")
+	}
+	return weighted, nil
+}
+
+// pictureAreas returns the area of every picture of the layout.
+func pictureAreas(layout TemplateLayout) []float64 {
+	areas := make([]float64, 0, len(layout.Dimensions))
+	for _, dim := range layout.Dimensions {
+		if len(dim) == 2 {
+			areas = append(areas, dim[0]*dim[1])
+		}
+	}
+	return areas
+}
+
+func sum(values []float64) float64 {
+	total := 0.0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+// scoreArea is the share of the available width × height covered by pictures. It
+// ranks candidates like the total picture area.
+func scoreArea(c LayoutCandidate) float64 {
+	available := c.AvailableWidth * c.AvailableHeight
+	if available <= 0 {
+		return 0
+	}
+	return math.Min(1, sum(pictureAreas(c.Layout))/available)
+}
+
+// scoreUniformity is 1 / (1 + coefficient of variation) of the picture areas: 1 when
+// all pictures are the same size.
+func scoreUniformity(c LayoutCandidate) float64 {
+	areas := pictureAreas(c.Layout)
+	if len(areas) == 0 {
+		return 0
+	}
+	mean := sum(areas) / float64(len(areas))
+	if mean <= 0 {
+		return 0
+	}
+	variance := 0.0
+	for _, a := range areas {
+		variance += (a - mean) * (a - mean)
+	}
+	variance /= float64(len(areas))
+	return 1 / (1 + math.Sqrt(variance)/mean)
+}
+
+// scoreWhitespace is the share of the layout block (full width × block height) covered
+// by pictures. Spacing and the side margins of a scaled-down block lower it.
+func scoreWhitespace(c LayoutCandidate) float64 {
+	block := c.AvailableWidth * c.Layout.TotalHeight
+	if block <= 0 {
+		return 0
+	}
+	return math.Min(1, sum(pictureAreas(c.Layout))/block)
+}
+
+// scoreReadingOrder is 1 minus the share of picture pairs that appear out of order when
+// the layout is read in rows, left to right and top to bottom. Pictures whose tops are
+// within 2% of the block height of each other count as one row.
+func scoreReadingOrder(c LayoutCandidate) float64 {
+	positions := c.Layout.Positions
+	n := len(positions)
+	if n < 2 {
+		return 1
+	}
+	tolerance := 1.0
+	if c.Layout.TotalHeight > 0 {
+		tolerance = c.Layout.TotalHeight * 0.02
+	}
+	before := func(i, j int) bool {
+		if math.Abs(positions[i][1]-positions[j][1]) > tolerance {
+			return positions[i][1] < positions[j][1]
+		}
+		return positions[i][0] < positions[j][0]
+	}
+	inversions := 0
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			if len(positions[i]) == 2 && len(positions[j]) == 2 && before(j, i) {
+				inversions++
+			}
+		}
+	}
+	return 1 - float64(inversions)/float64(n*(n-1)/2)
+}
+
+// TemplateChoice records the candidates rated when choosing a template layout for a
+// group of pictures. It is attached to the page when expose_scores is on. Trial layouts
+// of the split rules are recorded too, so a group can appear more than once.
+type TemplateChoice struct {
+	EntryID         int64            `json:"entry_id"`
+	Pictures        []int            `json:"pictures"` // Picture.Index of the group
+	AvailableHeight float64          `json:"available_height"`
+	Scorer          string           `json:"scorer"`
+	Chosen          string           `json:"chosen"` // 为空表示没有模板满足最小高度
+	Candidates      []CandidateScore `json:"candidates"`
+}
+
+// CandidateScore is the evaluation of one template.
+type CandidateScore struct {
+	Template        string             `json:"template"`
+	Valid           bool               `json:"valid"`             // met the minimum picture heights
+	Cropped         bool               `json:"cropped,omitempty"` // evaluated with cropped pictures
+	Scale           float64            `json:"scale,omitempty"`
+	ViolationFactor float64            `json:"violation_factor,omitempty"` // required / actual height of the worst picture
+	Score           float64            `json:"score"`
+	Scores          map[string]float64 `json:"scores,omitempty"` // components of the weighted scorer
+	Error           string             `json:"error,omitempty"`
+	// MinHeights lists the minimum height check of every picture. It is only filled
+	// when the layout is traced.
+	MinHeights []MinHeightCheck `json:"min_heights,omitempty"`
+}
+
+// MinHeightCheck compares the height of a picture in a candidate layout with the
+// minimum height for its type and the number of pictures.
+type MinHeightCheck struct {
+	Picture  int     `json:"picture"` // Picture.Index
+	Type     string  `json:"type"`    // see GetPictureType
+	Height   float64 `json:"height"`
+	Required float64 `json:"required"`
+	Met      bool    `json:"met"`
+}
+
+// recordTemplateChoice attaches choice to the current page when scores are exposed,
+// and traces it.
+func (e *ContinuousLayoutEngine) recordTemplateChoice(pictures []Picture, choice TemplateChoice) {
+	if !e.config.ExposeScores && e.trace == nil {
+		return
+	}
+	if e.activeEntry != nil {
+		choice.EntryID = e.activeEntry.ID
+	}
+	choice.Pictures = pictureIndices(pictures)
+	// evaluateTemplate knows the pictures by their position in the group only.
+	for _, candidate := range choice.Candidates {
+		for i := range candidate.MinHeights {
+			if check := &candidate.MinHeights[i]; check.Picture < len(pictures) {
+				check.Picture = pictures[check.Picture].Index
+			}
+		}
+	}
+	if e.config.ExposeScores {
+		e.currentPage.TemplateChoices = append(e.currentPage.TemplateChoices, choice)
+	}
+	e.traceDecision(TraceDecision{Kind: TraceTemplateChoice, Pictures: choice.Pictures, Choice: &choice})
+}
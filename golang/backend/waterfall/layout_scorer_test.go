@@ -0,0 +1,89 @@
+package waterfall
+
+import (
+	"math"
+	"testing"
+)
+
+// scorerCandidate returns a candidate of two pictures side by side, in a block 210
+// wide and 100 tall with 200 of height available.
+func scorerCandidate(positions, dimensions [][]float64) LayoutCandidate {
+	return LayoutCandidate{
+		Layout:          TemplateLayout{Positions: positions, Dimensions: dimensions, TotalHeight: 100, TotalWidth: 210},
+		Scale:           1,
+		AvailableWidth:  210,
+		AvailableHeight: 200,
+	}
+}
+
+func TestBuiltinScorers(t *testing.T) {
+	even := scorerCandidate([][]float64{{0, 0}, {110, 0}}, [][]float64{{100, 100}, {100, 100}})
+	uneven := scorerCandidate([][]float64{{0, 0}, {110, 0}}, [][]float64{{100, 100}, {50, 50}})
+	reversed := scorerCandidate([][]float64{{110, 0}, {0, 0}}, [][]float64{{100, 100}, {100, 100}})
+	stacked := scorerCandidate([][]float64{{0, 0}, {0, 50}}, [][]float64{{100, 45}, {100, 45}})
+	empty := LayoutCandidate{}
+
+	tests := []struct {
+		scorer    string
+		candidate LayoutCandidate
+		want      float64
+	}{
+		{ScorerArea, even, 20000.0 / (210 * 200)},
+		{ScorerArea, uneven, 12500.0 / (210 * 200)},
+		{ScorerArea, empty, 0},
+		{ScorerUniformity, even, 1},
+		{ScorerUniformity, uneven, 1 / 1.6}, // areas 10000 and 2500: deviation 3750, mean 6250
+		{ScorerUniformity, empty, 0},
+		{ScorerWhitespace, even, 20000.0 / (210 * 100)},
+		{ScorerWhitespace, uneven, 12500.0 / (210 * 100)},
+		{ScorerWhitespace, empty, 0},
+		{ScorerReadingOrder, even, 1},
+		{ScorerReadingOrder, reversed, 0},
+		{ScorerReadingOrder, stacked, 1},
+	}
+	for _, tt := range tests {
+		if got := builtinScorers[tt.scorer].Score(tt.candidate); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s scorer = %.4f, want %.4f", tt.scorer, got, tt.want)
+		}
+	}
+}
+
+func TestWeightedScorer(t *testing.T) {
+	cfg := DefaultLayoutConfig()
+	cfg.Scorer = ScorerWeighted
+	cfg.ScorerWeights = map[string]float64{ScorerUniformity: 3, ScorerReadingOrder: 1}
+	scorer, err := cfg.layoutScorer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	uneven := scorerCandidate([][]float64{{110, 0}, {0, 0}}, [][]float64{{100, 100}, {50, 50}})
+	if got, want := scorer.Score(uneven), (3*(1/1.6)+1*0)/4; math.Abs(got-want) > 1e-9 {
+		t.Errorf("weighted score = %.4f, want %.4f", got, want)
+	}
+	if scorer.Name() != ScorerWeighted {
+		t.Errorf("Name() = %q", scorer.Name())
+	}
+}
+
+func TestLayoutScorerConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		scorer  string
+		weights map[string]float64
+		ok      bool
+	}{
+		{"built-in", ScorerUniformity, nil, true},
+		{"unknown", "beauty", nil, false},
+		{"weighted", ScorerWeighted, map[string]float64{ScorerArea: 1, ScorerWhitespace: 0}, true},
+		{"unknown weight", ScorerWeighted, map[string]float64{"beauty": 1}, false},
+		{"negative weight", ScorerWeighted, map[string]float64{ScorerArea: -1, ScorerWhitespace: 2}, false},
+		{"no positive weight", ScorerWeighted, map[string]float64{ScorerArea: 0}, false},
+	}
+	for _, tt := range tests {
+		cfg := DefaultLayoutConfig()
+		cfg.Scorer, cfg.ScorerWeights = tt.scorer, tt.weights
+		if _, err := cfg.layoutScorer(); (err == nil) != tt.ok {
+			t.Errorf("%s: layoutScorer error %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package waterfall
+
+import (
+	"fmt"
+	"sync"
+)
+
+// minTemplatePictures is the smallest picture count laid out with templates; one and
+// two pictures have their own placement rules.
+const minTemplatePictures = 3
+
+// LayoutTemplate is a fixed arrangement of a number of pictures, such as 2T3B (two on
+// top, three below). Calculate returns the arrangement at full width for pictures with
+// the given aspect ratios (W/H) and types; the engine scales it to the available height
+// and checks the minimum picture heights.
+type LayoutTemplate interface {
+	Name() string
+	PictureCount() int
+	Calculate(ARs []float64, types []string, width, spacing float64) (TemplateLayout, error)
+}
+
+// NewLayoutTemplate returns a LayoutTemplate backed by calc.
+func NewLayoutTemplate(name string, count int, calc func(ARs []float64, types []string, width, spacing float64) (TemplateLayout, error)) LayoutTemplate {
+	return funcTemplate{name: name, count: count, calc: calc}
+}
+
+type funcTemplate struct {
+	name  string
+	count int
+	calc  func(ARs []float64, types []string, width, spacing float64) (TemplateLayout, error)
+}
+
+func (t funcTemplate) Name() string      { return t.name }
+func (t funcTemplate) PictureCount() int { return t.count }
+
+func (t funcTemplate) Calculate(ARs []float64, types []string, width, spacing float64) (TemplateLayout, error) {
+	return t.calc(ARs, types, width, spacing)
+}
+
+// layoutTemplates is the registry of templates, in registration order.
+var layoutTemplates struct {
+	sync.RWMutex
+	list   []LayoutTemplate
+	byName map[string]LayoutTemplate
+}
+
+// RegisterLayoutTemplate adds t to the templates considered for its picture count.
+// Names are unique across all counts, so a template can be disabled by name alone.
+func RegisterLayoutTemplate(t LayoutTemplate) error {
+	if t.Name() == "" {
+		return fmt.Errorf("layout template has no name")
+	}
+	if n := t.PictureCount(); n < minTemplatePictures || n > maxTemplatePictures {
+		return fmt.Errorf("layout template %s: picture count must be between %d and %d (got %d)", t.Name(), minTemplatePictures, maxTemplatePictures, n)
+	}
+	layoutTemplates.Lock()
+	defer layoutTemplates.Unlock()
+	if _, ok := layoutTemplates.byName[t.Name()]; ok {
+		return fmt.Errorf("layout template %s is already registered", t.Name())
+	}
+	if layoutTemplates.byName == nil {
+		layoutTemplates.byName = make(map[string]LayoutTemplate)
+	}
+	layoutTemplates.byName[t.Name()] = t
+	layoutTemplates.list = append(layoutTemplates.list, t)
+	return nil
+}
+
+// LayoutTemplates returns the registered templates for count pictures, in registration order.
+func LayoutTemplates(count int) []LayoutTemplate {
+	layoutTemplates.RLock()
+	defer layoutTemplates.RUnlock()
+	var templates []LayoutTemplate
+	for _, t := range layoutTemplates.list {
+		if t.PictureCount() == count {
+			templates = append(templates, t)
+		}
+	}
+	return templates
+}
+
+func lookupLayoutTemplate(name string) (LayoutTemplate, bool) {
+	layoutTemplates.RLock()
+	defer layoutTemplates.RUnlock()
+	t, ok := layoutTemplates.byName[name]
+	return t, ok
+}
+
+// builtinTemplates are the hand-derived templates of the layout_*.go files.
+var builtinTemplates = []LayoutTemplate{
+	NewLayoutTemplate("1L2R", 3, calculateLayout_1L2R),
+	NewLayoutTemplate("2L1R", 3, calculateLayout_2L1R),
+	NewLayoutTemplate("1T2B", 3, calculateLayout_1T2B),
+	NewLayoutTemplate("2T1B", 3, calculateLayout_2T1B),
+	NewLayoutTemplate("3Row", 3, calculateLayout_3Row),
+	NewLayoutTemplate("3Col", 3, calculateLayout_3Col),
+
+	NewLayoutTemplate("2x2", 4, calculateLayout_4_2x2),
+	NewLayoutTemplate("1T3B", 4, calculateLayout_4_1T3B),
+	NewLayoutTemplate("3T1B", 4, calculateLayout_4_3T1B),
+	NewLayoutTemplate("1L3R", 4, calculateLayout_4_1L3R),
+	NewLayoutTemplate("3L1R", 4, calculateLayout_4_3L1R),
+
+	NewLayoutTemplate("2T3B", 5, calculateLayout_5_2T3B),
+	NewLayoutTemplate("3T2B", 5, calculateLayout_5_3T2B),
+	NewLayoutTemplate("2T2M1B", 5, calculateLayout_5_2T2M1B),
+	NewLayoutTemplate("2T1M2B", 5, calculateLayout_5_2T1M2B),
+	NewLayoutTemplate("1T2M2B", 5, calculateLayout_5_1T2M2B),
+
+	NewLayoutTemplate("3T3B", 6, calculateLayout_6_3T3B),
+	NewLayoutTemplate("3L3R", 6, calculateLayout_6_3L3R),
+	NewLayoutTemplate("2T2M2B", 6, calculateLayout_6_2T2M2B),
+	NewLayoutTemplate("2T3M1B", 6, calculateLayout_6_2T3M1B),
+	NewLayoutTemplate("1T3M2B", 6, calculateLayout_6_1T3M2B),
+	NewLayoutTemplate("1T2M3B", 6, calculateLayout_6_1T2M3B),
+	NewLayoutTemplate("3T2M1B", 6, calculateLayout_6_3T2M1B),
+
+	NewLayoutTemplate("3T3M1B", 7, calculateLayout_7_3T3M1B),
+	NewLayoutTemplate("1T3M3B", 7, calculateLayout_7_1T3M3B),
+	NewLayoutTemplate("3T1M3B", 7, calculateLayout_7_3T1M3B),
+	NewLayoutTemplate("2T3M2B", 7, calculateLayout_7_2T3M2B),
+	NewLayoutTemplate("2T2M3B", 7, calculateLayout_7_2T2M3B),
+	NewLayoutTemplate("3T2M2B", 7, calculateLayout_7_3T2M2B),
+	NewLayoutTemplate("1T2M2M2B", 7, calculateLayout_7_1T2M2M2B),
+	NewLayoutTemplate("2T2M2M1B", 7, calculateLayout_7_2T2M2M1B),
+	NewLayoutTemplate("1T2M3M1B", 7, calculateLayout_7_1T2M3M1B),
+	NewLayoutTemplate("3T2M1M1B", 7, calculateLayout_7_3T2M1M1B),
+	NewLayoutTemplate("3L4R", 7, calculateLayout_7_3L4R),
+	NewLayoutTemplate("4L3R", 7, calculateLayout_7_4L3R),
+
+	NewLayoutTemplate("3T3M2B", 8, calculateLayout_8_3T3M2B),
+	NewLayoutTemplate("2T3M3B", 8, calculateLayout_8_2T3M3B),
+	NewLayoutTemplate("3T2M3B", 8, calculateLayout_8_3T2M3B),
+	NewLayoutTemplate("2T2M2M2B", 8, calculateLayout_8_2T2M2M2B),
+	NewLayoutTemplate("3T2M2M1B", 8, calculateLayout_8_3T2M2M1B),
+	NewLayoutTemplate("1T2M2M3B", 8, calculateLayout_8_1T2M2M3B),
+	NewLayoutTemplate("2T3M2M1B", 8, calculateLayout_8_2T3M2M1B),
+	NewLayoutTemplate("1T2M3M2B", 8, calculateLayout_8_1T2M3M2B),
+
+	NewLayoutTemplate("3T3M2M1B", 9, calculateLayout_9_3T3M2M1B),
+	NewLayoutTemplate("3T2M2M2B", 9, calculateLayout_9_3T2M2M2B),
+	NewLayoutTemplate("2T3M2M2B", 9, calculateLayout_9_2T3M2M2B),
+	NewLayoutTemplate("2T2M3M2B", 9, calculateLayout_9_2T2M3M2B),
+	NewLayoutTemplate("2T2M2M3B", 9, calculateLayout_9_2T2M2M3B),
+	NewLayoutTemplate("3T3M3B", 9, calculateLayout_9_3T3M3B),
+}
+
+func init() {
+	for _, t := range builtinTemplates {
+		if err := RegisterLayoutTemplate(t); err != nil {
+			panic(err)
+		}
+	}
+}
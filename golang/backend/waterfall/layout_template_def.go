@@ -0,0 +1,274 @@
+package waterfall
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TemplateDefinition describes a template by its structure instead of Go code.
+// Exactly one of Rows and Columns is set:
+//
+//   - Rows "2,3,2,1": full-width rows of 2, 3, 2 and 1 pictures, top to bottom. The
+//     pictures of a row share one height.
+//   - Columns "3|4": side-by-side stacks of 3 and 4 pictures, left to right. The
+//     pictures of a stack share one width, and all stacks have the same height.
+//
+// Pictures fill the rows or stacks in order.
+type TemplateDefinition struct {
+	Name    string `json:"name" yaml:"name"`
+	Rows    string `json:"rows,omitempty" yaml:"rows,omitempty"`       // 每行图片数，用逗号分隔
+	Columns string `json:"columns,omitempty" yaml:"columns,omitempty"` // 每列图片数，用竖线分隔
+}
+
+// templateFile is the document format of a template file.
+type templateFile struct {
+	Templates []TemplateDefinition `json:"templates" yaml:"templates"`
+}
+
+// groups returns the picture counts of the rows or columns and whether they are columns.
+func (d TemplateDefinition) groups() ([]int, bool, error) {
+	if (d.Rows == "") == (d.Columns == "") {
+		return nil, false, fmt.Errorf("template %s: exactly one of rows and columns must be set", d.Name)
+	}
+	spec, sep, columns := d.Rows, ",", false
+	if d.Columns != "" {
+		spec, sep, columns = d.Columns, "|", true
+	}
+	var counts []int
+	total := 0
+	for _, field := range strings.Split(spec, sep) {
+		n, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || n < 1 {
+			return nil, false, fmt.Errorf("template %s: invalid picture count %q in %q", d.Name, field, spec)
+		}
+		counts = append(counts, n)
+		total += n
+	}
+	if total < minTemplatePictures || total > maxTemplatePictures {
+		return nil, false, fmt.Errorf("template %s: %q holds %d pictures, must be between %d and %d", d.Name, spec, total, minTemplatePictures, maxTemplatePictures)
+	}
+	return counts, columns, nil
+}
+
+// Template returns the LayoutTemplate solving d.
+func (d TemplateDefinition) Template() (LayoutTemplate, error) {
+	if d.Name == "" {
+		return nil, fmt.Errorf("template has no name")
+	}
+	counts, columns, err := d.groups()
+	if err != nil {
+		return nil, err
+	}
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+	solve := solveRowsLayout
+	if columns {
+		solve = solveColumnsLayout
+	}
+	return NewLayoutTemplate(d.Name, total, func(ARs []float64, types []string, width, spacing float64) (TemplateLayout, error) {
+		if len(ARs) != total {
+			return TemplateLayout{}, fmt.Errorf("%s layout requires %d ARs (got %d)", d.Name, total, len(ARs))
+		}
+		layout, err := solve(counts, ARs, width, spacing)
+		if err != nil {
+			return TemplateLayout{}, fmt.Errorf("%s: %w", d.Name, err)
+		}
+		return layout, nil
+	}), nil
+}
+
+// solveRowsLayout stacks full-width rows of counts[i] pictures.
+func solveRowsLayout(counts []int, ARs []float64, AW, spacing float64) (TemplateLayout, error) {
+	layout := TemplateLayout{TotalWidth: AW}
+	y, start := 0.0, 0
+	for row, n := range counts {
+		widths, height, err := calculateRowLayout(ARs[start:start+n], AW, spacing)
+		if err != nil {
+			return TemplateLayout{}, fmt.Errorf("row %d: %w", row+1, err)
+		}
+		x := 0.0
+		for _, w := range widths {
+			layout.Positions = append(layout.Positions, []float64{x, y})
+			layout.Dimensions = append(layout.Dimensions, []float64{w, height})
+			x += w + spacing
+		}
+		y += height + spacing
+		start += n
+	}
+	layout.TotalHeight = y - spacing
+	return layout, nil
+}
+
+// solveColumnsLayout places stacks of counts[j] pictures side by side. A stack of
+// width W holding pictures with inverse aspect ratio sum S is W*S + (n-1)*spacing
+// tall; requiring equal heights H and widths summing to AW gives
+//
+//	H = (AW - (m-1)*spacing + Σ (n_j-1)*spacing/S_j) / Σ 1/S_j
+//
+// for m stacks, and W_j = (H - (n_j-1)*spacing) / S_j.
+func solveColumnsLayout(counts []int, ARs []float64, AW, spacing float64) (TemplateLayout, error) {
+	invSums := make([]float64, len(counts))
+	start := 0
+	for j, n := range counts {
+		for _, ar := range ARs[start : start+n] {
+			if ar <= 1e-6 {
+				return TemplateLayout{}, fmt.Errorf("invalid aspect ratio (%.2f) in column %d", ar, j+1)
+			}
+			invSums[j] += 1 / ar
+		}
+		start += n
+	}
+
+	numerator := AW - float64(len(counts)-1)*spacing
+	denominator := 0.0
+	for j, n := range counts {
+		numerator += float64(n-1) * spacing / invSums[j]
+		denominator += 1 / invSums[j]
+	}
+	H := numerator / denominator
+	if H <= 1e-6 || math.IsNaN(H) {
+		return TemplateLayout{}, fmt.Errorf("columns geometry infeasible (H=%.2f)", H)
+	}
+
+	layout := TemplateLayout{TotalHeight: H, TotalWidth: AW}
+	x := 0.0
+	start = 0
+	for j, n := range counts {
+		W := (H - float64(n-1)*spacing) / invSums[j]
+		if W <= 1e-6 {
+			return TemplateLayout{}, fmt.Errorf("columns geometry infeasible (column %d width %.2f)", j+1, W)
+		}
+		y := 0.0
+		for _, ar := range ARs[start : start+n] {
+			layout.Positions = append(layout.Positions, []float64{x, y})
+			layout.Dimensions = append(layout.Dimensions, []float64{W, W / ar})
+			y += W/ar + spacing
+		}
+		x += W + spacing
+		start += n
+	}
+	return layout, nil
+}
+
+// LoadTemplateDefinitions reads every .json, .yaml and .yml file in dir, in name order.
+// Each file holds a list of definitions under "templates".
+func LoadTemplateDefinitions(dir string) ([]TemplateDefinition, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".json", ".yaml", ".yml":
+			files = append(files, entry.Name())
+		}
+	}
+	sort.Strings(files)
+
+	var defs []TemplateDefinition
+	for _, name := range files {
+		path := filepath.Join(dir, name)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		unmarshal, err := unmarshalerFor(strings.TrimPrefix(strings.ToLower(filepath.Ext(name)), "."))
+		if err != nil {
+			return nil, err
+		}
+		var file templateFile
+		if err := unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("template file %s: %w", path, err)
+		}
+		defs = append(defs, file.Templates...)
+	}
+	return defs, nil
+}
+
+// definedTemplates returns the templates of the config's template_dir, checking that
+// every definition is valid and that names do not clash with each other or with the
+// registered templates.
+func (c LayoutConfig) definedTemplates() ([]LayoutTemplate, error) {
+	if c.TemplateDir == "" {
+		return nil, nil
+	}
+	defs, err := LoadTemplateDefinitions(c.TemplateDir)
+	if err != nil {
+		return nil, fmt.Errorf("template_dir: %w", err)
+	}
+	var templates []LayoutTemplate
+	seen := make(map[string]bool)
+	for _, def := range defs {
+		t, err := def.Template()
+		if err != nil {
+			return nil, fmt.Errorf("template_dir: %w", err)
+		}
+		if _, ok := lookupLayoutTemplate(t.Name()); ok || seen[t.Name()] {
+			return nil, fmt.Errorf("template_dir: template %s is defined more than once", t.Name())
+		}
+		seen[t.Name()] = true
+		templates = append(templates, t)
+	}
+	return templates, nil
+}
+
+// FindLayoutTemplate returns the registered template or the template from cfg's
+// template_dir with the given name.
+func FindLayoutTemplate(cfg LayoutConfig, name string) (LayoutTemplate, error) {
+	if t, ok := lookupLayoutTemplate(name); ok {
+		return t, nil
+	}
+	defined, err := cfg.definedTemplates()
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range defined {
+		if t.Name() == name {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown layout template %q", name)
+}
+
+// PreviewLayoutTemplate calculates t for pictures with the given aspect ratios at the
+// printable width and image spacing of cfg, without scaling it to a page. Positions
+// are relative to the top-left corner of the block, in cfg.DPI pixels.
+func PreviewLayoutTemplate(cfg LayoutConfig, t LayoutTemplate, ARs []float64) (TemplateLayout, error) {
+	if len(ARs) != t.PictureCount() {
+		return TemplateLayout{}, fmt.Errorf("template %s needs %d aspect ratios (got %d)", t.Name(), t.PictureCount(), len(ARs))
+	}
+	types := make([]string, len(ARs))
+	for i, ar := range ARs {
+		if ar <= 0 || math.IsNaN(ar) || math.IsInf(ar, 0) {
+			return TemplateLayout{}, fmt.Errorf("aspect ratio %d must be positive and finite (got %v)", i+1, ar)
+		}
+		types[i] = GetPictureType(ar)
+	}
+	e := NewContinuousLayoutEngineWithConfig(nil, cfg)
+	layout, err := t.Calculate(ARs, types, e.availableWidth, e.imageSpacing)
+	if err != nil {
+		return TemplateLayout{}, err
+	}
+	// Extreme aspect ratios can still overflow the calculation.
+	values := []float64{layout.TotalWidth, layout.TotalHeight}
+	for i := range layout.Positions {
+		values = append(append(values, layout.Positions[i]...), layout.Dimensions[i]...)
+	}
+	for _, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return TemplateLayout{}, fmt.Errorf("template %s cannot be laid out for aspect ratios %v", t.Name(), ARs)
+		}
+	}
+	return layout, nil
+}
@@ -0,0 +1,93 @@
+package waterfall
+
+import (
+	"math"
+	"testing"
+)
+
+const (
+	testWidth   = 1000.0
+	testSpacing = 10.0
+)
+
+var testARs = []float64{1.5, 0.75, 1, 1.78, 0.5, 1.33}
+
+func TestSolveRowsLayout(t *testing.T) {
+	counts := []int{2, 3, 1}
+	layout, err := solveRowsLayout(counts, testARs, testWidth, testSpacing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkAspectRatios(t, layout, testARs)
+
+	start, height := 0, -testSpacing
+	for row, n := range counts {
+		y, h := layout.Positions[start][1], layout.Dimensions[start][1]
+		width := -testSpacing
+		for i := start; i < start+n; i++ {
+			if layout.Positions[i][1] != y || math.Abs(layout.Dimensions[i][1]-h) > 1e-6 {
+				t.Errorf("row %d: picture %d is not aligned with the row", row+1, i)
+			}
+			width += layout.Dimensions[i][0] + testSpacing
+		}
+		if math.Abs(width-testWidth) > 1e-6 {
+			t.Errorf("row %d is %.2f wide, want %.2f", row+1, width, testWidth)
+		}
+		height += h + testSpacing
+		start += n
+	}
+	if math.Abs(layout.TotalHeight-height) > 1e-6 {
+		t.Errorf("TotalHeight = %.2f, rows take %.2f", layout.TotalHeight, height)
+	}
+}
+
+func TestSolveColumnsLayout(t *testing.T) {
+	counts := []int{1, 3, 2}
+	layout, err := solveColumnsLayout(counts, testARs, testWidth, testSpacing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkAspectRatios(t, layout, testARs)
+
+	start, width := 0, -testSpacing
+	for col, n := range counts {
+		x, w := layout.Positions[start][0], layout.Dimensions[start][0]
+		height := -testSpacing
+		for i := start; i < start+n; i++ {
+			if layout.Positions[i][0] != x || math.Abs(layout.Dimensions[i][0]-w) > 1e-6 {
+				t.Errorf("column %d: picture %d is not aligned with the column", col+1, i)
+			}
+			height += layout.Dimensions[i][1] + testSpacing
+		}
+		if math.Abs(height-layout.TotalHeight) > 1e-6 {
+			t.Errorf("column %d is %.2f tall, want %.2f", col+1, height, layout.TotalHeight)
+		}
+		width += w + testSpacing
+		start += n
+	}
+	if math.Abs(width-testWidth) > 1e-6 {
+		t.Errorf("columns take %.2f, want %.2f", width, testWidth)
+	}
+}
+
+func TestSolveLayoutInvalidAspectRatio(t *testing.T) {
+	ARs := []float64{1, 0, 1}
+	if _, err := solveRowsLayout([]int{1, 2}, ARs, testWidth, testSpacing); err == nil {
+		t.Error("solveRowsLayout accepted an aspect ratio of 0")
+	}
+	if _, err := solveColumnsLayout([]int{1, 2}, ARs, testWidth, testSpacing); err == nil {
+		t.Error("solveColumnsLayout accepted an aspect ratio of 0")
+	}
+}
+
+func checkAspectRatios(t *testing.T, layout TemplateLayout, ARs []float64) {
+	t.Helper()
+	if len(layout.Dimensions) != len(ARs) {
+		t.Fatalf("layout places %d pictures, want %d", len(layout.Dimensions), len(ARs))
+	}
+	for i, dim := range layout.Dimensions {
+		if ar := dim[0] / dim[1]; math.Abs(ar-ARs[i]) > 1e-6 {
+			t.Errorf("picture %d has aspect ratio %.4f, want %.4f", i, ar, ARs[i])
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package waterfall
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+)
+
+// logger receives the diagnostics of the layout engine; nil means slog.Default().
+// Debug records follow the layout decisions, warnings and errors report content that
+// could not be laid out as configured.
+var logger *slog.Logger
+
+// discardLogger drops every record without formatting it. Trial layouts use it.
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.Level(math.MaxInt32)}))
+
+// SetLogger sets the logger of engines created from now on; nil restores
+// slog.Default(). Records carry the function that logged them as "source" and, within
+// an engine, the page and entry being laid out. It is not synchronized with layouts
+// running concurrently.
+func SetLogger(l *slog.Logger) {
+	logger = l
+}
+
+func packageLogger() *slog.Logger {
+	if logger != nil {
+		return logger
+	}
+	return slog.Default()
+}
+
+// logf logs a message formatted from format and args at level on l. The message is
+// only formatted when the level is enabled.
+func logf(l *slog.Logger, level slog.Level, attrs []slog.Attr, format string, args ...any) {
+	ctx := context.Background()
+	if !l.Enabled(ctx, level) {
+		return
+	}
+	l.LogAttrs(ctx, level, fmt.Sprintf(format, args...), attrs...)
+}
+
+// warnf logs a warning from code outside the engine, such as template calculations.
+func warnf(source, format string, args ...any) {
+	logf(packageLogger(), slog.LevelWarn, []slog.Attr{slog.String("source", source)}, format, args...)
+}
+
+// logAt logs a message with the engine's position: the current page and active entry.
+func (e *ContinuousLayoutEngine) logAt(level slog.Level, source, format string, args ...any) {
+	if !e.logger.Enabled(context.Background(), level) {
+		return
+	}
+	attrs := []slog.Attr{slog.String("source", source)}
+	if e.currentPage != nil {
+		attrs = append(attrs, slog.Int("page", e.currentPage.Page))
+	}
+	if e.activeEntry != nil {
+		attrs = append(attrs, slog.Int64("entry", e.activeEntry.ID))
+	}
+	logf(e.logger, level, attrs, format, args...)
+}
+
+func (e *ContinuousLayoutEngine) debugf(source, format string, args ...any) {
+	e.logAt(slog.LevelDebug, source, format, args...)
+}
+
+func (e *ContinuousLayoutEngine) warnf(source, format string, args ...any) {
+	e.logAt(slog.LevelWarn, source, format, args...)
+}
+
+func (e *ContinuousLayoutEngine) errorf(source, format string, args ...any) {
+	e.logAt(slog.LevelError, source, format, args...)
+}
@@ -0,0 +1,115 @@
+package waterfall
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ReferenceDPI is the resolution LayoutConfig lengths are expressed in.
+// The engine rescales them when the config selects a different DPI.
+const ReferenceDPI = 300.0
+
+const mmPerInch = 25.4
+
+// Page orientations accepted by LayoutConfig.Orientation.
+const (
+	OrientationPortrait  = "portrait"
+	OrientationLandscape = "landscape"
+)
+
+// PageSize is a physical page size in millimetres, given in portrait orientation
+// (width <= height) except for square sizes.
+type PageSize struct {
+	Name     string  `json:"name"`
+	WidthMM  float64 `json:"width_mm"`
+	HeightMM float64 `json:"height_mm"`
+}
+
+// pageSizeCatalog lists the named page sizes, keyed by lower-case name.
+var pageSizeCatalog = map[string]PageSize{
+	"a4":        {Name: "A4", WidthMM: 210, HeightMM: 297},
+	"a5":        {Name: "A5", WidthMM: 148, HeightMM: 210},
+	"letter":    {Name: "Letter", WidthMM: 215.9, HeightMM: 279.4},
+	"8x8in":     {Name: "8x8in", WidthMM: 203.2, HeightMM: 203.2},
+	"210x210mm": {Name: "210x210mm", WidthMM: 210, HeightMM: 210},
+}
+
+// PageSizes returns the names of all catalog page sizes in sorted order.
+func PageSizes() []string {
+	names := make([]string, 0, len(pageSizeCatalog))
+	for _, size := range pageSizeCatalog {
+		names = append(names, size.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var customPageSizePattern = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*x\s*(\d+(?:\.\d+)?)\s*(mm|cm|in)$`)
+
+// ParsePageSize resolves a catalog name ("A4", "letter") or a custom size written as
+// "<width>x<height><unit>" with unit mm, cm or in (e.g. "148x210mm", "8x10in").
+func ParsePageSize(name string) (PageSize, error) {
+	key := strings.ToLower(strings.TrimSpace(name))
+	if size, ok := pageSizeCatalog[key]; ok {
+		return size, nil
+	}
+
+	m := customPageSizePattern.FindStringSubmatch(key)
+	if m == nil {
+		return PageSize{}, fmt.Errorf("unknown page size %q (catalog: %s; or custom like 148x210mm, 8x10in)", name, strings.Join(PageSizes(), ", "))
+	}
+	w, _ := strconv.ParseFloat(m[1], 64)
+	h, _ := strconv.ParseFloat(m[2], 64)
+	unit := 1.0
+	switch m[3] {
+	case "cm":
+		unit = 10
+	case "in":
+		unit = mmPerInch
+	}
+	if w <= 0 || h <= 0 {
+		return PageSize{}, fmt.Errorf("page size %q must have positive dimensions", name)
+	}
+	return PageSize{Name: strings.TrimSpace(name), WidthMM: w * unit, HeightMM: h * unit}, nil
+}
+
+// Pixels returns the page dimensions at dpi, rounded to whole pixels, with the
+// orientation applied.
+func (p PageSize) Pixels(dpi float64, orientation string) (width, height float64) {
+	width = math.Round(p.WidthMM / mmPerInch * dpi)
+	height = math.Round(p.HeightMM / mmPerInch * dpi)
+	if orientation == OrientationLandscape && width < height {
+		width, height = height, width
+	}
+	return width, height
+}
+
+// PageDimensions returns the configured page size in pixels at the layout DPI.
+func (c LayoutConfig) PageDimensions() (width, height float64, err error) {
+	size, err := ParsePageSize(c.PageSize)
+	if err != nil {
+		return 0, 0, err
+	}
+	width, height = size.Pixels(c.DPI, c.Orientation)
+	return width, height, nil
+}
+
+// dpiScale is the factor that converts config lengths (ReferenceDPI) to layout pixels.
+func (c LayoutConfig) dpiScale() float64 {
+	if c.DPI <= 0 {
+		return 1
+	}
+	return c.DPI / ReferenceDPI
+}
+
+// OutputScale is the factor that converts layout pixels to OutputDPI coordinates.
+func (c LayoutConfig) OutputScale() float64 {
+	if c.DPI <= 0 {
+		return 1
+	}
+	return c.OutputDPI / c.DPI
+}
@@ -0,0 +1,172 @@
+package waterfall
+
+import "math"
+
+// Costs of the pagination optimizer, in units of one empty page.
+const (
+	paginationRelaxationCost = 1.0 // every relaxation recorded on a page
+	paginationScaleCost      = 1.0 // a template block scaled down, times the share it lost in width
+)
+
+// pictureChoice is how the pagination optimizer has an entry's pictures placed.
+type pictureChoice int
+
+const (
+	// pictureChoiceFlow leaves scales and split points to the split rules.
+	pictureChoiceFlow pictureChoice = iota
+	// pictureChoiceFullScale does not scale template blocks down to the rest of a
+	// page, so the split rules split them or move them to the next page instead. A
+	// block at the top of a page is still scaled down to fit it.
+	pictureChoiceFullScale
+	// pictureChoiceNewPage moves pictures that would be split across pages to the next
+	// page, as keep_together.picture_groups does for all entries.
+	pictureChoiceNewPage
+)
+
+// optimizePageBreaks chooses the entries that start a new page and how each entry's
+// pictures are placed, minimizing the bottom whitespace of all pages but the last,
+// the scaling down of picture blocks and the relaxations needed to place content (see
+// paginationCost). It returns the entries to break before and a choice per entry.
+//
+// An entry that starts on a fresh page is laid out independently of the entries
+// before it, so the optimum for entries[i:] is the cheapest choice of the next forced
+// break j > i: entries[i:j] flowing from a fresh page, plus the optimum for
+// entries[j:]. The flow of entries[i:j] is laid out once per i, an entry at a time,
+// and measured after every entry. An entry whose pictures were scaled down or ran
+// onto a new page is laid out again with the other picture choices, and keeps the one
+// that makes the flow up to it cheapest; the other entries place their pictures
+// the same with every choice. With a pagination window of w, segments hold at most w
+// entries.
+func (e *ContinuousLayoutEngine) optimizePageBreaks() (map[int]bool, []pictureChoice) {
+	n := len(e.entries)
+	window := e.config.PaginationWindow
+	if window <= 0 || window > n {
+		window = n
+	}
+
+	best := make([]float64, n+1)
+	next := make([]int, n+1)
+	segmentChoices := make([][]pictureChoice, n)
+	for i := n - 1; i >= 0; i-- {
+		best[i] = math.Inf(1)
+		end := min(n, i+window)
+		choices := make([]pictureChoice, 0, end-i)
+		sim := e.paginationSegment(i, choices)
+		for k := i; k < end; k++ {
+			final := k+1 == n
+			pages, scaleLoss := len(sim.pages), sim.scaleLoss
+			sim.pictureChoice = pictureChoiceFlow
+			sim.processEntry(e.entries[k], e.entries[k].ID)
+			cost, choice := sim.paginationCost(final), pictureChoiceFlow
+
+			var alternatives []pictureChoice
+			if len(e.entries[k].Pictures) > 0 && sim.scaleLoss > scaleLoss {
+				alternatives = append(alternatives, pictureChoiceFullScale)
+			}
+			if len(e.entries[k].Pictures) > 0 && len(sim.pages) > pages {
+				alternatives = append(alternatives, pictureChoiceNewPage)
+			}
+			for _, alternative := range alternatives {
+				alt := e.paginationSegment(i, append(choices, alternative))
+				if altCost := alt.paginationCost(final); altCost < cost {
+					sim, cost, choice = alt, altCost, alternative
+				}
+			}
+			choices = append(choices, choice)
+
+			// On ties the longer segment wins, so breaks are only forced where they help.
+			if cost+best[k+1] <= best[i] {
+				best[i], next[i] = cost+best[k+1], k+1
+			}
+		}
+		segmentChoices[i] = choices
+	}
+
+	breaks := make(map[int]bool)
+	choices := make([]pictureChoice, 0, n)
+	for i := 0; i < n; i = next[i] {
+		if i > 0 {
+			breaks[i] = true
+		}
+		choices = append(choices, segmentChoices[i][:next[i]-i]...)
+	}
+	e.debugf("Pagination", "%d entries, page breaks forced before %d of them (cost %.3f).", n, len(breaks), best[0])
+	return breaks, choices
+}
+
+// paginationSegment returns a trial engine that laid out the entries from i on a fresh
+// page, one per choice, with their pictures placed as chosen.
+func (e *ContinuousLayoutEngine) paginationSegment(i int, choices []pictureChoice) *ContinuousLayoutEngine {
+	sim := e.simulation(e.entries[i : i+len(choices)])
+	sim.newPage()
+	for k, choice := range choices {
+		sim.pictureChoice = choice
+		sim.processEntry(e.entries[i+k], e.entries[i+k].ID)
+	}
+	return sim
+}
+
+// simulation returns an engine laying out entries with e's config and plug-ins, for
+// trial layouts. Trial layouts are neither logged nor traced.
+func (e *ContinuousLayoutEngine) simulation(entries []Entry) *ContinuousLayoutEngine {
+	cfg := e.config
+	cfg.OptimizePagination = false
+	cfg.ExposeScores = false
+	cfg.Trace = false
+	cfg.Strict = false
+	cfg.TemplateDir = "" // e's templates are shared below instead of read again
+	sim := NewContinuousLayoutEngineWithConfig(entries, cfg)
+	sim.SetFirstPageNumber(e.firstPageNumber)
+	sim.logger = discardLogger
+	sim.templates = e.templates
+	sim.disabledTemplates = e.disabledTemplates
+	sim.measurer = e.measurer
+	sim.scorer = e.scorer
+	sim.imageLoader = e.imageLoader
+	if e.focusCache == nil {
+		e.focusCache = make(map[string]*FocalPoint)
+	}
+	sim.focusCache = e.focusCache
+	return sim
+}
+
+// paginationCost rates the pages laid out so far: the share of the printable height
+// left empty at the bottom of every page, except the last one when final is set, plus
+// paginationRelaxationCost for every relaxation and paginationScaleCost for the
+// template blocks scaled down to fit a page.
+func (e *ContinuousLayoutEngine) paginationCost(final bool) float64 {
+	cost := paginationScaleCost * e.scaleLoss
+	for p, page := range e.pages {
+		cost += paginationRelaxationCost * float64(len(page.Relaxations))
+		if final && p == len(e.pages)-1 {
+			continue
+		}
+		empty := e.marginTop + e.availableHeight - pageContentBottom(page, e.marginTop)
+		cost += math.Max(0, empty) / e.availableHeight
+	}
+	return cost
+}
+
+// pageContentBottom returns the lowest edge of the content on page, or top when the
+// page is empty.
+func pageContentBottom(page ContinuousLayoutPage, top float64) float64 {
+	bottom := top
+	extend := func(area [][]float64) {
+		if len(area) == 2 && len(area[1]) == 2 {
+			bottom = math.Max(bottom, area[1][1])
+		}
+	}
+	for _, entry := range page.Entries {
+		extend(entry.TimeArea)
+		for _, area := range entry.TextAreas {
+			extend(area)
+		}
+		for _, pic := range entry.Pictures {
+			extend(pic.Area)
+		}
+		for _, run := range entry.InlineRuns {
+			extend(run.Area)
+		}
+	}
+	return bottom
+}
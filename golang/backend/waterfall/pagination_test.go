@@ -0,0 +1,116 @@
+package waterfall
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// paginationEntries returns n entries of varying text length and picture count.
+func paginationEntries(n int, seed int64) []Entry {
+	rng := rand.New(rand.NewSource(seed))
+	entries := make([]Entry, n)
+	for i := range entries {
+		entry := Entry{
+			ID:   int64(i + 1),
+			Time: "2025年3月30日 17:50",
+			Text: strings.Repeat("今天天气很好，我们去公园散步。", rng.Intn(12)),
+		}
+		for p := rng.Intn(7); p > 0; p-- {
+			entry.Pictures = append(entry.Pictures, Picture{
+				Index:  len(entry.Pictures),
+				Width:  600 + rng.Intn(3000),
+				Height: 600 + rng.Intn(3000),
+			})
+		}
+		entries[i] = entry
+	}
+	return entries
+}
+
+func paginationLayout(t *testing.T, entries []Entry, cfg LayoutConfig) (*ContinuousLayoutEngine, []ContinuousLayoutPage) {
+	t.Helper()
+	e := NewContinuousLayoutEngineWithConfig(entries, cfg)
+	e.logger = discardLogger
+	pages, err := e.ProcessEntries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e, pages
+}
+
+func TestOptimizePaginationNotWorseThanGreedy(t *testing.T) {
+	for seed := int64(1); seed <= 20; seed++ {
+		entries := paginationEntries(12, seed)
+		cfg := DefaultLayoutConfig()
+		cfg.VerticalJustification.Enabled = false
+
+		greedy, _ := paginationLayout(t, entries, cfg)
+		cfg.OptimizePagination = true
+		optimized, pages := paginationLayout(t, entries, cfg)
+
+		greedyCost, optimizedCost := greedy.paginationCost(true), optimized.paginationCost(true)
+		if optimizedCost > greedyCost+1e-9 {
+			t.Errorf("seed %d: optimized cost %.4f exceeds greedy cost %.4f", seed, optimizedCost, greedyCost)
+		}
+		if placed := countPlacedPictures(pages); placed != countPictures(entries) {
+			t.Errorf("seed %d: %d of %d pictures placed", seed, placed, countPictures(entries))
+		}
+	}
+}
+
+func TestOptimizePageBreaksWindow(t *testing.T) {
+	entries := paginationEntries(6, 1)
+	cfg := DefaultLayoutConfig()
+	cfg.PaginationWindow = 1
+	e := NewContinuousLayoutEngineWithConfig(entries, cfg)
+	e.logger = discardLogger
+
+	// Segments of one entry leave the optimizer no choice but a break before each.
+	breaks, choices := e.optimizePageBreaks()
+	if breaks[0] {
+		t.Error("break forced before the first entry")
+	}
+	if len(choices) != len(entries) {
+		t.Fatalf("%d picture choices for %d entries", len(choices), len(entries))
+	}
+	for i := 1; i < len(entries); i++ {
+		if !breaks[i] {
+			t.Errorf("no break before entry %d with a window of 1", i)
+		}
+	}
+}
+
+func TestOptimizePageBreaksPictureChoices(t *testing.T) {
+	// Some of these flows are cheaper with pictures kept at full scale or together.
+	chosen := map[pictureChoice]int{}
+	for seed := int64(12); seed <= 16; seed++ {
+		e := NewContinuousLayoutEngineWithConfig(paginationEntries(12, seed), DefaultLayoutConfig())
+		e.logger = discardLogger
+		_, choices := e.optimizePageBreaks()
+		for _, choice := range choices {
+			chosen[choice]++
+		}
+	}
+	if chosen[pictureChoiceFullScale] == 0 || chosen[pictureChoiceNewPage] == 0 {
+		t.Errorf("picture choices %v, want both full scale and new page chosen", chosen)
+	}
+}
+
+func countPictures(entries []Entry) int {
+	n := 0
+	for _, entry := range entries {
+		n += len(entry.Pictures)
+	}
+	return n
+}
+
+func countPlacedPictures(pages []ContinuousLayoutPage) int {
+	n := 0
+	for _, page := range pages {
+		for _, entry := range page.Entries {
+			n += len(entry.Pictures)
+		}
+	}
+	return n
+}
@@ -1,9 +1,6 @@
 package waterfall
 
-import (
-	"fmt"
-	"math"
-)
+import "math"
 
 // --- Actual implementation for the refactored function ---
 // processSinglePictureLayoutAndPlace calculates and places a single picture
@@ -18,7 +15,7 @@ func (e *ContinuousLayoutEngine) processSinglePictureLayoutAndPlace(picture Pict
 		aspectRatio = float64(picture.Width) / float64(picture.Height)
 		validAR = true
 	} else {
-		fmt.Printf("Warning: Invalid dimensions for picture index %d. Using default AR=1.\n", picture.Index)
+		e.warnf("processSinglePictureLayoutAndPlace", "Invalid dimensions for picture index %d. Using default AR=1.", picture.Index)
 		// Attempt to use a default size based on generic min height?
 	}
 
@@ -29,7 +26,7 @@ func (e *ContinuousLayoutEngine) processSinglePictureLayoutAndPlace(picture Pict
 
 	// Handle invalid AR or zero available height with a fallback
 	if !validAR || layoutAvailableHeight <= 1e-6 {
-		fmt.Printf("Warning: Using fallback dimensions for Pic %d due to invalid AR or no available height.\n", picture.Index)
+		e.warnf("processSinglePictureLayoutAndPlace", "Using fallback dimensions for Pic %d due to invalid AR or no available height.", picture.Index)
 		// Use min landscape height for 1 picture as fallback, capped by available height
 		finalHeight = math.Min(e.minLandscapeHeights[1], layoutAvailableHeight)
 		if finalHeight < 1.0 {
@@ -88,6 +85,7 @@ func (e *ContinuousLayoutEngine) processSinglePictureLayoutAndPlace(picture Pict
 
 	// --- Place Picture (Horizontally Centered) ---
 	e.placeSinglePicture(picture, finalWidth, finalHeight)
+	e.checkMinHeights([]Picture{picture}, []float64{finalHeight})
 
 	return finalHeight // Return the actual height used for placement
 }
@@ -102,7 +100,7 @@ func (e *ContinuousLayoutEngine) placeSinglePicture(pic Picture, width, height f
 
 	// Ensure entry exists
 	if len(e.currentPage.Entries) == 0 {
-		fmt.Println("Warning: Placing single picture but no entry exists on current page. Creating one.")
+		e.warnf("placeSinglePicture", "Placing single picture but no entry exists on current page. Creating one.")
 		e.currentPage.Entries = append(e.currentPage.Entries, PageEntry{})
 	}
 	currentEntry := &e.currentPage.Entries[len(e.currentPage.Entries)-1]
@@ -119,7 +117,7 @@ func (e *ContinuousLayoutEngine) placeSinglePicture(pic Picture, width, height f
 		math.IsNaN(absY0) || math.IsInf(absY0, 0) ||
 		math.IsNaN(absX1) || math.IsInf(absX1, 0) ||
 		math.IsNaN(absY1) || math.IsInf(absY1, 0) {
-		fmt.Printf("Error: Invalid coordinates calculated for picture %d: [%.2f, %.2f], [%.2f, %.2f]\n",
+		e.errorf("placeSinglePicture", "Invalid coordinates calculated for picture %d: [%.2f, %.2f], [%.2f, %.2f]",
 			pic.Index, absX0, absY0, absX1, absY1)
 		// Skip appending the picture if coordinates are invalid
 		return
@@ -136,5 +134,6 @@ func (e *ContinuousLayoutEngine) placeSinglePicture(pic Picture, width, height f
 		Width:  int(math.Round(width)),  // Store final layout width (rounded)
 		Height: int(math.Round(height)), // Store final layout height (rounded)
 	})
+	e.tracePlacement("placeSinglePicture", []Picture{pic}, height, 0)
 	// currentY updated by the caller (processSinglePictureLayoutAndPlace) using the returned height
 }
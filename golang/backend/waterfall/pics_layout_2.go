@@ -1,14 +1,10 @@
 package waterfall
 
 import (
-	"errors"
 	"fmt"
 	"math"
 )
 
-// ErrLayoutExceedsAvailableHeight indicates the calculated layout cannot fit.
-var ErrLayoutExceedsAvailableHeight = errors.New("calculated layout height exceeds available height")
-
 // calculateTwoPicturesLayout calculates the layout information for two pictures
 // without placing them. It determines the layout type (up/down or left/right),
 // calculates dimensions based on available space, checks minimum height constraints,
@@ -32,7 +28,7 @@ func (e *ContinuousLayoutEngine) calculateTwoPicturesLayout(pictures []Picture,
 	}
 
 	if !validAR1 || !validAR2 || layoutAvailableHeight <= 1e-6 {
-		fmt.Printf("Warning: Cannot calculate layout for 2 pictures due to invalid AR or zero available height.\\n")
+		e.warnf("calculateTwoPicturesLayout", "Cannot calculate layout for 2 pictures due to invalid AR or zero available height.")
 		return TemplateLayout{}, fmt.Errorf("invalid input: non-positive available height or invalid picture dimensions")
 	}
 
@@ -85,7 +81,7 @@ func (e *ContinuousLayoutEngine) calculateTwoPicturesLayout(pictures []Picture,
 
 		// *** Check if initial layout fits available height BEFORE min height check ***
 		if totalRequiredHeight > layoutAvailableHeight+tolerance {
-			fmt.Printf("Debug (calculate2 UpDown): Initial required height %.2f exceeds available %.2f\\n", totalRequiredHeight, layoutAvailableHeight)
+			e.debugf("calculate2 UpDown", "Initial required height %.2f exceeds available %.2f", totalRequiredHeight, layoutAvailableHeight)
 			return TemplateLayout{TotalHeight: totalRequiredHeight}, ErrLayoutExceedsAvailableHeight // Return specific error
 		}
 
@@ -115,7 +111,7 @@ func (e *ContinuousLayoutEngine) calculateTwoPicturesLayout(pictures []Picture,
 
 		// *** Check if initial layout fits available height BEFORE min height check ***
 		if calculatedTotalHeight > layoutAvailableHeight+tolerance { // Check rowHeight before scaling
-			fmt.Printf("Debug (calculate2 LeftRight): Initial required height %.2f exceeds available %.2f\\n", calculatedTotalHeight, layoutAvailableHeight)
+			e.debugf("calculate2 LeftRight", "Initial required height %.2f exceeds available %.2f", calculatedTotalHeight, layoutAvailableHeight)
 			return TemplateLayout{TotalHeight: calculatedTotalHeight}, ErrLayoutExceedsAvailableHeight // Return specific error
 		}
 
@@ -167,7 +163,7 @@ func (e *ContinuousLayoutEngine) calculateTwoPicturesLayout(pictures []Picture,
 	}
 
 	if finalScaledHeight < minRequiredHeight-1e-6 { // Check final scaled height against min required
-		fmt.Printf("Debug (calculate2): Final scaled layout height %.2f violates minimum height constraint %.2f\\n", finalScaledHeight, minRequiredHeight)
+		e.debugf("calculate2", "Final scaled layout height %.2f violates minimum height constraint %.2f", finalScaledHeight, minRequiredHeight)
 		// Return calculated info even on error, caller might need it
 		finalLayout.Positions = positions
 		finalLayout.Dimensions = dimensions
@@ -193,7 +189,7 @@ func (e *ContinuousLayoutEngine) calculateTwoPicturesLayout(pictures []Picture,
 // Pagination is handled before this function. It fits the layout into layoutAvailableHeight.
 func (e *ContinuousLayoutEngine) processTwoPicturesLayoutAndPlace(pictures []Picture, layoutAvailableHeight float64) float64 {
 	if len(pictures) != 2 {
-		fmt.Printf("Error: processTwoPicturesLayoutAndPlace called with %d pictures. Skipping.\n", len(pictures))
+		e.errorf("processTwoPicturesLayoutAndPlace", "processTwoPicturesLayoutAndPlace called with %d pictures. Skipping.", len(pictures))
 		return 0
 	}
 
@@ -211,7 +207,7 @@ func (e *ContinuousLayoutEngine) processTwoPicturesLayoutAndPlace(pictures []Pic
 
 	// Fallback if any AR is invalid or available height is zero
 	if !validAR1 || !validAR2 || layoutAvailableHeight <= 1e-6 {
-		fmt.Printf("Warning: Using fallback layout for 2 pictures due to invalid AR or no available height.\n")
+		e.warnf("processTwoPicturesLayoutAndPlace", "Using fallback layout for 2 pictures due to invalid AR or no available height.")
 		// Fallback to simple left/right layout with min height, scaled if needed
 		widths, _, rowHeight := e.calculateUniformRowHeightLayout(pictures, e.availableWidth)
 
@@ -235,6 +231,7 @@ func (e *ContinuousLayoutEngine) processTwoPicturesLayoutAndPlace(pictures []Pic
 			widths[1] *= scale
 		}
 		e.placePictureRow(pictures, widths, finalHeight)
+		e.checkRowMinHeight(pictures, finalHeight)
 		return finalHeight
 	}
 
@@ -293,6 +290,7 @@ func (e *ContinuousLayoutEngine) processTwoPicturesLayoutAndPlace(pictures []Pic
 		startY := e.currentY
 		e.placeSinglePictureStacked(pic1, finalWidth1, finalHeight1, startY)
 		e.placeSinglePictureStacked(pic2, finalWidth2, finalHeight2, startY+finalHeight1+e.imageSpacing)
+		e.checkMinHeights(pictures, []float64{finalHeight1, finalHeight2})
 
 	case "left_right":
 		// Use existing uniform height layout logic
@@ -309,6 +307,7 @@ func (e *ContinuousLayoutEngine) processTwoPicturesLayoutAndPlace(pictures []Pic
 		}
 		finalTotalHeight = finalHeight
 		e.placePictureRow(pictures, widths, finalHeight)
+		e.checkRowMinHeight(pictures, finalHeight)
 	}
 
 	return finalTotalHeight
@@ -325,7 +324,7 @@ func (e *ContinuousLayoutEngine) placeSinglePictureStacked(pic Picture, width, h
 
 	// Ensure entry exists
 	if len(e.currentPage.Entries) == 0 {
-		fmt.Println("Warning: Placing stacked picture but no entry exists on current page. Creating one.")
+		e.warnf("placeSinglePictureStacked", "Placing stacked picture but no entry exists on current page. Creating one.")
 		e.currentPage.Entries = append(e.currentPage.Entries, PageEntry{})
 	}
 	currentEntry := &e.currentPage.Entries[len(e.currentPage.Entries)-1]
@@ -341,7 +340,7 @@ func (e *ContinuousLayoutEngine) placeSinglePictureStacked(pic Picture, width, h
 		math.IsNaN(absY0) || math.IsInf(absY0, 0) ||
 		math.IsNaN(absX1) || math.IsInf(absX1, 0) ||
 		math.IsNaN(absY1) || math.IsInf(absY1, 0) {
-		fmt.Printf("Error: Invalid coordinates calculated for stacked picture %d: [%.2f, %.2f], [%.2f, %.2f]\n",
+		e.errorf("placeSinglePictureStacked", "Invalid coordinates calculated for stacked picture %d: [%.2f, %.2f], [%.2f, %.2f]",
 			pic.Index, absX0, absY0, absX1, absY1)
 		return
 	}
@@ -357,6 +356,7 @@ func (e *ContinuousLayoutEngine) placeSinglePictureStacked(pic Picture, width, h
 		Width:  int(math.Round(width)),
 		Height: int(math.Round(height)),
 	})
+	e.tracePlacement("placeSinglePictureStacked", []Picture{pic}, height, 0)
 	// e.currentY is managed by the calling function (processTwoPicturesLayoutAndPlace)
 }
 
@@ -380,7 +380,7 @@ func (e *ContinuousLayoutEngine) placePictureRow(pictures []Picture, widths []fl
 	// Add pictures to the current entry
 	if len(e.currentPage.Entries) == 0 {
 		// This should be handled before placePictureRow is called
-		fmt.Println("Warning: Placing picture row but no entry exists on current page. Creating one.")
+		e.warnf("placePictureRow", "Placing picture row but no entry exists on current page. Creating one.")
 		e.currentPage.Entries = append(e.currentPage.Entries, PageEntry{})
 	}
 	currentEntry := &e.currentPage.Entries[len(e.currentPage.Entries)-1]
@@ -402,6 +402,7 @@ func (e *ContinuousLayoutEngine) placePictureRow(pictures []Picture, widths []fl
 			currentX += widths[i] + e.imageSpacing // Add spacing between images (Rule 3.11)
 		}
 	}
+	e.tracePlacement("placePictureRow", pictures, rowHeight, 0)
 	// currentY updated by caller (processTwoPicturesLayoutAndPlace)
 }
 
@@ -441,7 +442,7 @@ func (e *ContinuousLayoutEngine) calculateUniformRowHeightLayout(pictures []Pict
 		finalRowHeight = rowAvailableWidth / totalAspectRatioSum
 	} else if validARCount > 0 {
 		// Handle case where all ARs are zero or invalid leading to zero sum
-		finalRowHeight = math.Min(e.availableHeight, 2500.0) // Use min height or available page height
+		finalRowHeight = math.Min(e.availableHeight, 2500.0*e.dpiScale) // Use min height or available page height
 	} else {
 		return nil, nil, 0 // No valid pictures
 	}
@@ -1,175 +0,0 @@
-package waterfall
-
-import (
-	"fmt"
-	"math"
-)
-
-// --- Main Calculation Function for 4 Pictures ---
-
-// calculateFourPicturesLayout implements the logic described in rules 4.1-4.6
-func (e *ContinuousLayoutEngine) calculateFourPicturesLayout(pictures []Picture, layoutAvailableHeight float64) (TemplateLayout, error) {
-	if len(pictures) != 4 {
-		return TemplateLayout{}, fmt.Errorf("incorrect number of pictures for 4-pic layout: %d", len(pictures))
-	}
-
-	spacing := e.imageSpacing
-	AW := e.availableWidth
-
-	// Get Aspect Ratios (W/H) and Types
-	ARs := make([]float64, 4)
-	types := make([]string, 4)
-	validARs := true
-	for i, pic := range pictures {
-		if pic.Height > 0 && pic.Width > 0 {
-			ARs[i] = float64(pic.Width) / float64(pic.Height)
-			types[i] = GetPictureType(ARs[i])
-		} else {
-			ARs[i] = 1.0 // Default AR
-			types[i] = "unknown"
-			validARs = false
-			fmt.Printf("Warning: Invalid dimensions for picture %d in 4-pic layout.\n", i)
-		}
-	}
-
-	if !validARs {
-		return TemplateLayout{}, fmt.Errorf("invalid dimensions encountered in 4-pic layout")
-	}
-
-	// --- Define Layout Calculation Functions Map ---
-	type calcFuncType func(*ContinuousLayoutEngine, []float64, []string, float64, float64) (TemplateLayout, error)
-	possibleLayouts := map[string]calcFuncType{
-		"2x2":  calculateLayout_4_2x2,
-		"1T3B": calculateLayout_4_1T3B,
-		"3T1B": calculateLayout_4_3T1B,
-		"1L3R": calculateLayout_4_1L3R,
-		"3L1R": calculateLayout_4_3L1R,
-	}
-
-	// --- Store results from all layout attempts ---
-	validLayouts := make(map[string]TemplateLayout)    // Layouts meeting strict minimums
-	layoutAreas := make(map[string]float64)            // Areas for strictly valid layouts
-	scaledLayouts := make(map[string]TemplateLayout)   // All calculated & scaled layouts
-	layoutViolationFactors := make(map[string]float64) // Max violation factor for each layout
-	var firstCalcError error
-
-	// --- Calculate and Evaluate All Layouts ---
-	for name, calcFunc := range possibleLayouts {
-		// Calculate initial layout
-		layout, err := calcFunc(e, ARs, types, AW, spacing)
-		if err != nil {
-			fmt.Printf("Debug: Error calculating initial layout %s: %v\n", name, err)
-			if firstCalcError == nil {
-				firstCalcError = fmt.Errorf("initial layout %s: %w", name, err)
-			}
-			layoutViolationFactors[name] = math.Inf(1) // Mark as non-viable if calculation fails
-			continue                                   // Skip to next layout
-		}
-
-		// --- Scale Layout if Needed ---
-		scale := 1.0
-		if layout.TotalHeight > layoutAvailableHeight {
-			if layout.TotalHeight > 1e-6 {
-				scale = layoutAvailableHeight / layout.TotalHeight
-				scaledLayout := TemplateLayout{
-					Positions:   make([][]float64, len(layout.Positions)),
-					Dimensions:  make([][]float64, len(layout.Dimensions)),
-					TotalHeight: layout.TotalHeight * scale,
-					TotalWidth:  layout.TotalWidth, // Assuming layout maintains AW
-				}
-				for i := range layout.Positions {
-					if len(layout.Positions[i]) == 2 {
-						scaledLayout.Positions[i] = []float64{layout.Positions[i][0] * scale, layout.Positions[i][1] * scale}
-					}
-					if len(layout.Dimensions[i]) == 2 {
-						scaledLayout.Dimensions[i] = []float64{layout.Dimensions[i][0] * scale, layout.Dimensions[i][1] * scale}
-					}
-				}
-				layout = scaledLayout // Use the scaled layout
-			} else {
-				fmt.Printf("Debug: Layout %s has zero/tiny height, skipping scaling.\n", name)
-				layoutViolationFactors[name] = math.Inf(1) // Mark as non-viable
-				continue
-			}
-		}
-
-		scaledLayouts[name] = layout // Store the final (potentially scaled) layout
-
-		// --- Check Minimum Heights After Scaling & Calculate Violation Factor ---
-		meetsScaledMin := true
-		maxViolationFactor := 1.0
-		for i, picType := range types {
-			requiredMinHeight := GetRequiredMinHeight(e, picType, len(pictures))
-			if i < len(layout.Dimensions) && len(layout.Dimensions[i]) == 2 {
-				actualHeight := layout.Dimensions[i][1]
-				if actualHeight < requiredMinHeight {
-					meetsScaledMin = false
-					if actualHeight > 1e-6 {
-						violationRatio := requiredMinHeight / actualHeight
-						if violationRatio > maxViolationFactor {
-							maxViolationFactor = violationRatio
-						}
-					} else {
-						maxViolationFactor = math.Inf(1)
-					}
-				}
-			} else {
-				fmt.Printf("Warning: Invalid dimensions data for layout %s, picture %d\n", name, i)
-				meetsScaledMin = false
-				maxViolationFactor = math.Inf(1)
-				break
-			}
-		}
-		layoutViolationFactors[name] = maxViolationFactor
-
-		// --- Store Strictly Valid Layout and Calculate Area ---
-		if meetsScaledMin {
-			validLayouts[name] = layout
-			totalArea := 0.0
-			for _, dim := range layout.Dimensions {
-				if len(dim) == 2 {
-					totalArea += dim[0] * dim[1]
-				}
-			}
-			layoutAreas[name] = totalArea
-			fmt.Printf("Debug: Layout %s valid (Scale: %.2f), Area: %.2f\n", name, scale, totalArea)
-		} else {
-			fmt.Printf("Debug: Layout %s failed minimum height check (Scale: %.2f, ViolationFactor: %.2f).\n", name, scale, maxViolationFactor)
-		}
-	}
-
-	// --- Select Best Layout or Signal Split/New Page ---
-	if len(validLayouts) > 0 {
-		// Strategy 1: Strict selection based on max area
-		bestLayoutName := ""
-		maxArea := -1.0
-		for name, area := range layoutAreas {
-			if area > maxArea {
-				maxArea = area
-				bestLayoutName = name
-			}
-		}
-		fmt.Printf("Debug: Selected best fitting valid 4-pic layout: %s (Area: %.2f)\n", bestLayoutName, maxArea)
-		return validLayouts[bestLayoutName], nil
-	} else {
-		// Strategy 2: No standard layout fits, determine if new page or split is needed
-		hasWideOrTall := false
-		for _, picType := range types {
-			if picType == "wide" || picType == "tall" {
-				hasWideOrTall = true
-				break
-			}
-		}
-
-		if hasWideOrTall {
-			fmt.Println("Debug: No fitting layout for 4 pics with wide/tall images. Signaling force_new_page.")
-			return TemplateLayout{}, fmt.Errorf("force_new_page")
-		} else {
-			fmt.Println("Debug: No fitting layout for 4 pics (no wide/tall). Signaling split_required.")
-			return TemplateLayout{}, fmt.Errorf("split_required")
-		}
-	}
-}
-
-// calculateLayout_4_2T2B calculates the 2 Top, 2 Bottom layout.
-// ... existing code ...
@@ -0,0 +1,192 @@
+package waterfall
+
+import (
+	"fmt"
+	"math"
+)
+
+// Picture layout modes.
+const (
+	PictureLayoutTemplates = "templates" // 模板布局，超宽/超长图按行排列
+	PictureLayoutJustified = "justified" // 等高行布局，按动态规划选择换行位置
+)
+
+// Costs of the justified row layout, in units of a row deviating from the target
+// height by 100%.
+const (
+	justifiedPageBreakCost = 4.0  // moving a row to the next page, times the square of the page share left empty
+	justifiedMinHeightCost = 10.0 // a row below the minimum height of one of its pictures
+)
+
+// justifiedRow is one row of a justified layout: pictures[start:end] at height.
+type justifiedRow struct {
+	start, end int
+	height     float64
+	width      float64 // narrower than the available width for capped rows
+	newPage    bool    // the row starts a new page
+	belowMin   float64 // largest minimum height the row misses, 0 if it meets all
+}
+
+// processJustifiedPictures lays pictures out in full-width rows of equal height,
+// choosing the row breaks with planJustifiedRows.
+func (e *ContinuousLayoutEngine) processJustifiedPictures(pictures []Picture) {
+	ARs := make([]float64, len(pictures))
+	for i, pic := range pictures {
+		ARs[i] = 1.0
+		if pic.Width > 0 && pic.Height > 0 {
+			ARs[i] = float64(pic.Width) / float64(pic.Height)
+		} else {
+			e.warnf("Justified", "Invalid dimensions for picture index %d. Using AR=1.", pic.Index)
+		}
+	}
+
+	rows := e.planJustifiedRows(ARs)
+	e.debugf("Justified", "%d pictures in %d rows.", len(pictures), len(rows))
+	for i, row := range rows {
+		if row.newPage {
+			e.newPage()
+		} else if i == 0 {
+			e.currentY += e.requiredSpacingBeforeElement()
+		} else {
+			e.currentY += e.imageSpacing
+		}
+
+		layout := TemplateLayout{TotalHeight: row.height, TotalWidth: row.width}
+		x := 0.0
+		for _, ar := range ARs[row.start:row.end] {
+			layout.Positions = append(layout.Positions, []float64{x, 0})
+			layout.Dimensions = append(layout.Dimensions, []float64{ar * row.height, row.height})
+			x += ar*row.height + e.imageSpacing
+		}
+		if row.belowMin > 0 {
+			e.recordMinHeightRelaxation(pictures[row.start:row.end], fmt.Sprintf("justified row height %.0f is below the minimum of %.0f", row.height, row.belowMin))
+		}
+		e.placePicturesInRow(pictures[row.start:row.end], layout)
+		e.currentY += row.height
+	}
+}
+
+// planJustifiedRows partitions pictures with the given aspect ratios into rows that
+// fill the available width, minimizing by dynamic programming the sum of
+//
+//   - the squared relative deviation of every row from the target row height,
+//   - a penalty for rows below the minimum height of their pictures,
+//   - a penalty for every row that does not fit the rest of its page, growing with the
+//     space it leaves empty there.
+//
+// The page position after each prefix of the pictures is taken from its cheapest
+// partition. A row taller than the page is capped at the page height, and the last
+// row at the target height; such rows are narrower than the available width.
+func (e *ContinuousLayoutEngine) planJustifiedRows(ARs []float64) []justifiedRow {
+	n := len(ARs)
+	target := e.justifiedRowHeight
+	pageBottom := e.marginTop + e.availableHeight
+
+	cost := make([]float64, n+1)
+	y := make([]float64, n+1)
+	last := make([]justifiedRow, n+1)
+	for j := 1; j <= n; j++ {
+		cost[j] = math.Inf(1)
+	}
+	y[0] = e.currentY
+
+	for i := 0; i < n; i++ {
+		if math.IsInf(cost[i], 1) {
+			continue
+		}
+		spacing := e.imageSpacing
+		if i == 0 {
+			spacing = e.requiredSpacingBeforeElement()
+		}
+		if y[i] <= e.marginTop {
+			spacing = 0
+		}
+
+		sumAR := 0.0
+		for j := i + 1; j <= n; j++ {
+			sumAR += ARs[j-1]
+			count := j - i
+			height := (e.availableWidth - float64(count-1)*e.imageSpacing) / sumAR
+			if height <= 1e-6 {
+				break
+			}
+			rowCost := 0.0
+			switch {
+			case j == n && height > target:
+				height = target // 最后一行不拉伸
+			case height > e.availableHeight:
+				height = e.availableHeight
+				rowCost = math.Pow((height-target)/target, 2)
+			default:
+				rowCost = math.Pow((height-target)/target, 2)
+			}
+			row := justifiedRow{start: i, end: j, height: height, width: height*sumAR + float64(count-1)*e.imageSpacing}
+
+			for _, ar := range ARs[i:j] {
+				if required := GetRequiredMinHeight(e, GetPictureType(ar), count); height < required {
+					row.belowMin = math.Max(row.belowMin, required)
+				}
+			}
+			if row.belowMin > 0 {
+				rowCost += justifiedMinHeightCost
+			}
+
+			rowY := y[i] + spacing + height
+			if remaining := pageBottom - y[i] - spacing; height > remaining+1e-6 && y[i] > e.marginTop {
+				row.newPage = true
+				rowY = e.marginTop + height
+				rowCost += justifiedPageBreakCost * math.Pow(math.Max(0, remaining)/e.availableHeight, 2)
+			}
+
+			if total := cost[i] + rowCost; total < cost[j] {
+				cost[j], y[j], last[j] = total, rowY, row
+			}
+			// Adding pictures only lowers the row further below the target.
+			if height < target/3 {
+				break
+			}
+		}
+	}
+
+	var rows []justifiedRow
+	for j := n; j > 0; j = last[j].start {
+		rows = append([]justifiedRow{last[j]}, rows...)
+	}
+	return rows
+}
+
+// recordMinHeightRelaxation records that pictures were placed below their minimum height.
+func (e *ContinuousLayoutEngine) recordMinHeightRelaxation(pictures []Picture, detail string) {
+	r := Relaxation{Rule: RelaxMinHeight, Detail: detail}
+	if e.activeEntry != nil {
+		r.EntryID = e.activeEntry.ID
+	}
+	for _, pic := range pictures {
+		r.Pictures = append(r.Pictures, pic.Index)
+	}
+	e.recordRelaxation(r, ErrMinHeightConstraint)
+}
+
+// checkMinHeights records a min_height relaxation when one of pictures, placed together
+// with the given heights, is below the minimum height for its type and their count.
+func (e *ContinuousLayoutEngine) checkMinHeights(pictures []Picture, heights []float64) {
+	for i, pic := range pictures {
+		ar := 1.0
+		if pic.Width > 0 && pic.Height > 0 {
+			ar = float64(pic.Width) / float64(pic.Height)
+		}
+		if required := GetRequiredMinHeight(e, GetPictureType(ar), len(pictures)); heights[i] < required-1e-6 {
+			e.recordMinHeightRelaxation(pictures, fmt.Sprintf("picture %d height %.0f is below the minimum of %.0f", pic.Index, heights[i], required))
+			return
+		}
+	}
+}
+
+// checkRowMinHeight is checkMinHeights for a row of pictures of the same height.
+func (e *ContinuousLayoutEngine) checkRowMinHeight(pictures []Picture, height float64) {
+	heights := make([]float64, len(pictures))
+	for i := range heights {
+		heights[i] = height
+	}
+	e.checkMinHeights(pictures, heights)
+}
@@ -0,0 +1,91 @@
+package waterfall
+
+import (
+	"math"
+	"testing"
+)
+
+func newJustifiedEngine(t *testing.T) *ContinuousLayoutEngine {
+	t.Helper()
+	cfg := DefaultLayoutConfig()
+	cfg.PictureLayout = "justified"
+	e := NewContinuousLayoutEngineWithConfig(nil, cfg)
+	e.logger = discardLogger
+	e.newPage()
+	return e
+}
+
+func TestPlanJustifiedRowsCoversPictures(t *testing.T) {
+	e := newJustifiedEngine(t)
+	ARs := []float64{1.5, 0.75, 1, 2.4, 0.5, 1.33, 1.78, 0.8, 1, 3.2, 0.6}
+
+	rows := e.planJustifiedRows(ARs)
+	if len(rows) == 0 {
+		t.Fatal("no rows planned")
+	}
+	next := 0
+	for i, row := range rows {
+		if row.start != next || row.end <= row.start {
+			t.Fatalf("row %d covers [%d, %d), want it to start at %d", i, row.start, row.end, next)
+		}
+		next = row.end
+
+		sumAR := 0.0
+		for _, ar := range ARs[row.start:row.end] {
+			sumAR += ar
+		}
+		gaps := float64(row.end-row.start-1) * e.imageSpacing
+		if width := row.height*sumAR + gaps; math.Abs(width-row.width) > 1e-6 {
+			t.Errorf("row %d is %.2f wide, its pictures take %.2f", i, row.width, width)
+		}
+		capped := row.height >= e.availableHeight-1e-6
+		if i < len(rows)-1 && !capped && math.Abs(row.width-e.availableWidth) > 1e-6 {
+			t.Errorf("row %d is %.2f wide, want the available width %.2f", i, row.width, e.availableWidth)
+		}
+		if row.width > e.availableWidth+1e-6 {
+			t.Errorf("row %d is %.2f wide, wider than the available width %.2f", i, row.width, e.availableWidth)
+		}
+	}
+	if next != len(ARs) {
+		t.Errorf("rows cover %d of %d pictures", next, len(ARs))
+	}
+}
+
+func TestPlanJustifiedRowsHitsTarget(t *testing.T) {
+	e := newJustifiedEngine(t)
+	// Three squares per row are exactly the target height.
+	e.justifiedRowHeight = (e.availableWidth - 2*e.imageSpacing) / 3
+	e.minLandscapeHeights = make([]float64, 9)
+	ARs := []float64{1, 1, 1, 1, 1, 1, 1, 1, 1}
+
+	rows := e.planJustifiedRows(ARs)
+	if len(rows) != 3 {
+		t.Fatalf("got %d rows, want 3", len(rows))
+	}
+	for i, row := range rows {
+		if row.end-row.start != 3 {
+			t.Errorf("row %d holds %d pictures, want 3", i, row.end-row.start)
+		}
+		if math.Abs(row.height-e.justifiedRowHeight) > 1e-6 {
+			t.Errorf("row %d is %.2f tall, want %.2f", i, row.height, e.justifiedRowHeight)
+		}
+		if row.belowMin > 0 {
+			t.Errorf("row %d is below the minimum height %.2f", i, row.belowMin)
+		}
+	}
+}
+
+func TestPlanJustifiedRowsBreaksPage(t *testing.T) {
+	e := newJustifiedEngine(t)
+	e.currentY = e.marginTop + e.availableHeight - 10
+
+	rows := e.planJustifiedRows([]float64{1.5, 1.5, 1.5})
+	if len(rows) == 0 || !rows[0].newPage {
+		t.Fatalf("first row %+v does not start a new page with 10px left", rows)
+	}
+	for i, row := range rows[1:] {
+		if row.newPage {
+			t.Errorf("row %d starts another new page", i+1)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package waterfall
+
+// partitionPictureGroups splits n pictures into the fewest groups of at most maxSize,
+// with sizes as even as possible and larger groups first (e.g. 10 -> 5,5; 19 -> 7,6,6).
+func partitionPictureGroups(n, maxSize int) []int {
+	if n <= 0 || maxSize <= 0 {
+		return nil
+	}
+	groups := (n + maxSize - 1) / maxSize
+	sizes := make([]int, groups)
+	for i := range sizes {
+		sizes[i] = n / groups
+		if i < n%groups {
+			sizes[i]++
+		}
+	}
+	return sizes
+}
+
+// processLargePictureSet lays out more pictures than a single template holds by
+// partitioning them into template-sized groups, in order, and placing each group with
+// the standard templated strategy. Each group paginates on its own, and the pictures a
+// group could not place go to the last-resort path before the next group starts.
+func (e *ContinuousLayoutEngine) processLargePictureSet(pictures []Picture) {
+	sizes := partitionPictureGroups(len(pictures), e.config.PictureGroupSize)
+	e.debugf("LargeSet", "%d pictures exceed the template maximum. Placing in groups %v.", len(pictures), sizes)
+	start := 0
+	for _, size := range sizes {
+		group := pictures[start : start+size]
+		mark := e.markPictures()
+		err := processPicturesOldStrategy(e, group)
+		e.placeMissingPictures(group, mark, err)
+		start += size
+	}
+}
@@ -0,0 +1,47 @@
+package waterfall
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPartitionPictureGroups(t *testing.T) {
+	tests := []struct {
+		n, maxSize int
+		want       []int
+	}{
+		{0, 9, nil},
+		{5, 0, nil},
+		{1, 9, []int{1}},
+		{9, 9, []int{9}},
+		{10, 9, []int{5, 5}},
+		{19, 9, []int{7, 6, 6}},
+		{20, 6, []int{5, 5, 5, 5}},
+		{25, 4, []int{4, 4, 4, 4, 3, 3, 3}},
+	}
+	for _, tt := range tests {
+		if got := partitionPictureGroups(tt.n, tt.maxSize); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("partitionPictureGroups(%d, %d) = %v, want %v", tt.n, tt.maxSize, got, tt.want)
+		}
+	}
+
+	for n := 1; n <= 60; n++ {
+		for maxSize := 1; maxSize <= 9; maxSize++ {
+			sizes := partitionPictureGroups(n, maxSize)
+			if len(sizes) != (n+maxSize-1)/maxSize {
+				t.Errorf("partitionPictureGroups(%d, %d) = %v, not the fewest groups", n, maxSize, sizes)
+			}
+			sum := 0
+			for i, size := range sizes {
+				sum += size
+				if size > maxSize || size < sizes[0]-1 || size > sizes[0] || (i > 0 && size > sizes[i-1]) {
+					t.Errorf("partitionPictureGroups(%d, %d) = %v, sizes uneven", n, maxSize, sizes)
+					break
+				}
+			}
+			if sum != n {
+				t.Errorf("partitionPictureGroups(%d, %d) = %v, sums to %d", n, maxSize, sizes, sum)
+			}
+		}
+	}
+}
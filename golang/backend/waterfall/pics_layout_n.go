@@ -0,0 +1,220 @@
+package waterfall
+
+import (
+	"fmt"
+	"math"
+)
+
+// scoreTieTolerance is the score difference below which two candidates tie, so that
+// rounding noise in the geometry does not decide between equally good layouts.
+const scoreTieTolerance = 1e-9
+
+// calculatePicturesLayout determines the best template layout for 3 to 9 pictures.
+// Every enabled template for the picture count is calculated at full width, scaled
+// down to layoutAvailableHeight if needed and checked against the minimum picture
+// heights; the valid layout rated highest by the configured LayoutScorer wins.
+// Scores within scoreTieTolerance of each other tie, and ties go to the candidate
+// evaluated first: templates in registration order, then those of template_dir, each
+// uncropped before cropped. The choice never depends on map order or timing.
+//
+// If no layout is valid, three pictures report ErrMinHeightConstraint, and larger sets
+// ErrNewPageRequired when a wide or tall picture is present and ErrSplitRequired
+// otherwise. Pictures without valid dimensions report ErrInvalidDimensions.
+func (e *ContinuousLayoutEngine) calculatePicturesLayout(pictures []Picture, layoutAvailableHeight float64) (TemplateLayout, error) {
+	numPics := len(pictures)
+	if numPics < minTemplatePictures || numPics > maxTemplatePictures {
+		return TemplateLayout{}, fmt.Errorf("incorrect number of pictures for template layout: %d", numPics)
+	}
+
+	// Get Aspect Ratios (W/H) and Types
+	ARs := make([]float64, numPics)
+	types := make([]string, numPics)
+	validARs := true
+	for i, pic := range pictures {
+		if pic.Height > 0 && pic.Width > 0 {
+			ARs[i] = float64(pic.Width) / float64(pic.Height)
+			types[i] = GetPictureType(ARs[i])
+		} else {
+			ARs[i] = 1.0 // Default AR
+			types[i] = "unknown"
+			validARs = false
+			e.warnf("calculatePicturesLayout", "Invalid dimensions for picture %d in %d-pic layout.", i, numPics)
+		}
+	}
+	if !validARs {
+		return TemplateLayout{}, fmt.Errorf("%d-pic layout: %w", numPics, ErrInvalidDimensions)
+	}
+
+	bestName := ""
+	var best TemplateLayout
+	bestScore := math.Inf(-1)
+	var firstCalcError error
+	choice := TemplateChoice{AvailableHeight: layoutAvailableHeight, Scorer: e.scorer.Name()}
+	consider := func(record CandidateScore, layout TemplateLayout, _ error) {
+		choice.Candidates = append(choice.Candidates, record)
+		if record.Valid && record.Score > bestScore+scoreTieTolerance {
+			bestName, best, bestScore = record.Template, layout, record.Score
+		}
+	}
+
+	for _, template := range e.layoutTemplates(numPics) {
+		record, layout, err := e.evaluateTemplate(template, ARs, types, layoutAvailableHeight, false)
+		if err != nil && firstCalcError == nil {
+			firstCalcError = fmt.Errorf("initial %d-pic layout %s: %w", numPics, template.Name(), err)
+		}
+		consider(record, layout, err)
+
+		// A template that had to be scaled down or missed the minimum heights may fit
+		// better with the pictures cropped within the crop budget.
+		if err == nil && e.cropBudget > 0 && (!record.Valid || record.Scale < 1) {
+			consider(e.evaluateTemplate(template, croppedARs(ARs, e.cropBudget), types, layoutAvailableHeight, true))
+		}
+	}
+
+	choice.Chosen = bestName
+	e.recordTemplateChoice(pictures, choice)
+	if bestName != "" {
+		e.debugf("calculatePicturesLayout", "Selected best fitting valid %d-pic layout: %s (Score: %.4f)", numPics, bestName, bestScore)
+		return best, nil
+	}
+	return TemplateLayout{}, e.noTemplateFitError(types, firstCalcError)
+}
+
+// evaluateTemplate calculates template for pictures with the given aspect ratios,
+// scales it down to layoutAvailableHeight if needed, checks the minimum picture
+// heights and scores it. cropped marks aspect ratios changed by cropping. The returned
+// error is the template's calculation error.
+func (e *ContinuousLayoutEngine) evaluateTemplate(template LayoutTemplate, ARs []float64, types []string, layoutAvailableHeight float64, cropped bool) (CandidateScore, TemplateLayout, error) {
+	numPics := len(ARs)
+	name := template.Name()
+	record := CandidateScore{Template: name, Cropped: cropped}
+	layout, err := template.Calculate(ARs, types, e.availableWidth, e.imageSpacing)
+	if err != nil {
+		e.debugf("evaluateTemplate", "Error calculating initial %d-pic layout %s: %v", numPics, name, err)
+		record.Error = err.Error()
+		return record, TemplateLayout{}, err
+	}
+
+	// --- Scale Layout if Needed ---
+	scale := 1.0
+	if layout.TotalHeight > layoutAvailableHeight {
+		if layout.TotalHeight <= 1e-6 {
+			e.debugf("evaluateTemplate", "%d-Pic Layout %s has zero/tiny height, skipping scaling.", numPics, name)
+			record.Error = "zero height"
+			return record, TemplateLayout{}, nil
+		}
+		scale = layoutAvailableHeight / layout.TotalHeight
+		layout = scaleTemplateLayout(layout, scale)
+	}
+	record.Scale = scale
+	if scale < 1 && e.pictureChoice == pictureChoiceFullScale && e.currentY > e.marginTop {
+		e.debugf("evaluateTemplate", "%d-Pic Layout %s would be scaled down (Scale: %.2f) below the top of the page; the entry's pictures keep full scale.", numPics, name, scale)
+		record.Error = "scaled down below the top of the page"
+		return record, layout, nil
+	}
+
+	// --- Check Minimum Heights After Scaling & Calculate Violation Factor ---
+	meetsScaledMin := true
+	maxViolationFactor := 1.0
+	for i, picType := range types {
+		requiredMinHeight := GetRequiredMinHeight(e, picType, numPics)
+		if i >= len(layout.Dimensions) || len(layout.Dimensions[i]) != 2 {
+			e.warnf("evaluateTemplate", "Invalid dimensions data for %d-pic layout %s, picture %d", numPics, name, i)
+			meetsScaledMin = false
+			maxViolationFactor = math.Inf(1)
+			break
+		}
+		actualHeight := layout.Dimensions[i][1]
+		if e.trace != nil {
+			record.MinHeights = append(record.MinHeights, MinHeightCheck{Picture: i, Type: picType, Height: actualHeight, Required: requiredMinHeight, Met: actualHeight >= requiredMinHeight})
+		}
+		if actualHeight < requiredMinHeight {
+			meetsScaledMin = false
+			if actualHeight > 1e-6 {
+				maxViolationFactor = math.Max(maxViolationFactor, requiredMinHeight/actualHeight)
+			} else {
+				maxViolationFactor = math.Inf(1)
+			}
+		}
+	}
+	if !math.IsInf(maxViolationFactor, 1) {
+		record.ViolationFactor = maxViolationFactor
+	}
+	if !meetsScaledMin {
+		e.debugf("evaluateTemplate", "%d-Pic Layout %s failed minimum height check (Scale: %.2f, ViolationFactor: %.2f).", numPics, name, scale, maxViolationFactor)
+		record.Error = "minimum height not met"
+		return record, layout, nil
+	}
+
+	candidate := LayoutCandidate{
+		Template:        name,
+		Layout:          layout,
+		Scale:           scale,
+		Cropped:         cropped,
+		AvailableWidth:  e.availableWidth,
+		AvailableHeight: layoutAvailableHeight,
+	}
+	record.Valid = true
+	record.Score = e.scorer.Score(candidate)
+	if weighted, ok := e.scorer.(WeightedScorer); ok && (e.config.ExposeScores || e.trace != nil) {
+		record.Scores = make(map[string]float64, len(weighted.Scorers))
+		for _, s := range weighted.Scorers {
+			record.Scores[s.Name()] = s.Score(candidate)
+		}
+	}
+	e.debugf("evaluateTemplate", "%d-Pic Layout %s valid (Scale: %.2f), Score (%s): %.4f", numPics, name, scale, e.scorer.Name(), record.Score)
+	return record, layout, nil
+}
+
+// noTemplateFitError is the error returned when no template layout is valid.
+func (e *ContinuousLayoutEngine) noTemplateFitError(types []string, firstCalcError error) error {
+	if len(types) == 3 {
+		e.debugf("3-Pic", "No layout found that satisfies minimum height requirements after scaling. Signaling error.")
+		if firstCalcError != nil {
+			return fmt.Errorf("no layout for 3 pictures: %w (%w)", ErrMinHeightConstraint, firstCalcError)
+		}
+		return fmt.Errorf("no layout for 3 pictures: %w", ErrMinHeightConstraint)
+	}
+	for _, picType := range types {
+		if picType == "wide" || picType == "tall" {
+			e.debugf("noTemplateFitError", "No fitting layout for %d pics with wide/tall images. Signaling a new page.", len(types))
+			return fmt.Errorf("no layout for %d pictures: %w", len(types), ErrNewPageRequired)
+		}
+	}
+	e.debugf("noTemplateFitError", "No fitting layout for %d pics (no wide/tall). Signaling a split.", len(types))
+	return fmt.Errorf("no layout for %d pictures: %w", len(types), ErrSplitRequired)
+}
+
+// scaleTemplateLayout returns a copy of layout with positions and sizes multiplied by
+// scale. TotalWidth is kept, as the layout block still spans the available width.
+func scaleTemplateLayout(layout TemplateLayout, scale float64) TemplateLayout {
+	scaled := TemplateLayout{
+		Positions:   make([][]float64, len(layout.Positions)),
+		Dimensions:  make([][]float64, len(layout.Dimensions)),
+		TotalHeight: layout.TotalHeight * scale,
+		TotalWidth:  layout.TotalWidth,
+	}
+	for i := range layout.Positions {
+		if len(layout.Positions[i]) == 2 {
+			scaled.Positions[i] = []float64{layout.Positions[i][0] * scale, layout.Positions[i][1] * scale}
+		}
+	}
+	for i := range layout.Dimensions {
+		if len(layout.Dimensions[i]) == 2 {
+			scaled.Dimensions[i] = []float64{layout.Dimensions[i][0] * scale, layout.Dimensions[i][1] * scale}
+		}
+	}
+	return scaled
+}
+
+// layoutTemplates returns the registered templates for count pictures followed by the
+// ones from the config's template_dir, without the ones disabled in the config.
+func (e *ContinuousLayoutEngine) layoutTemplates(count int) []LayoutTemplate {
+	var templates []LayoutTemplate
+	for _, t := range append(LayoutTemplates(count), e.templates...) {
+		if t.PictureCount() == count && !e.disabledTemplates[t.Name()] {
+			templates = append(templates, t)
+		}
+	}
+	return templates
+}
@@ -0,0 +1,43 @@
+package waterfall
+
+import "math"
+
+// croppedARs moves every aspect ratio as far towards square (1) as cropping at most
+// budget of the picture on each side allows: trimming the sides narrows a picture to
+// AR*(1-2*budget), trimming top and bottom widens it to AR/(1-2*budget).
+func croppedARs(ARs []float64, budget float64) []float64 {
+	keep := 1 - 2*budget
+	cropped := make([]float64, len(ARs))
+	for i, ar := range ARs {
+		cropped[i] = math.Max(ar*keep, math.Min(ar/keep, 1))
+	}
+	return cropped
+}
+
+// cropRect returns the region of pic, in source pixels, that is shown in a width ×
+// height box, or nil when the box has the picture's own aspect ratio. The region is
+// centered on the picture's focal point as far as the image bounds allow, and on the
+// image center when there is none.
+func (e *ContinuousLayoutEngine) cropRect(pic Picture, width, height float64) [][]float64 {
+	if e.cropBudget <= 0 || pic.Width <= 0 || pic.Height <= 0 || width <= 0 || height <= 0 {
+		return nil
+	}
+	srcW, srcH := float64(pic.Width), float64(pic.Height)
+	shown := width / height
+	if math.Abs(shown-srcW/srcH) <= 1e-3*shown {
+		return nil
+	}
+	cropW, cropH := srcW, srcH
+	if shown < srcW/srcH {
+		cropW = srcH * shown // narrower: trim left and right
+	} else {
+		cropH = srcW / shown // wider: trim top and bottom
+	}
+	centerX, centerY := 0.5, 0.5
+	if focus := e.pictureFocus(pic); focus != nil {
+		centerX, centerY = focus.center()
+	}
+	x0 := math.Max(0, math.Min(srcW-cropW, centerX*srcW-cropW/2))
+	y0 := math.Max(0, math.Min(srcH-cropH, centerY*srcH-cropH/2))
+	return [][]float64{{x0, y0}, {x0 + cropW, y0 + cropH}}
+}
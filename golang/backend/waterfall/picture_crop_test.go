@@ -0,0 +1,108 @@
+package waterfall
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestCroppedARs(t *testing.T) {
+	// A budget of 0.1 keeps 80% of the width or height.
+	got := croppedARs([]float64{2, 0.5, 1.1, 0.9, 1}, 0.1)
+	want := []float64{1.6, 0.625, 1, 1, 1}
+	for i := range want {
+		if math.Abs(got[i]-want[i]) > 1e-9 {
+			t.Errorf("croppedARs = %v, want %v", got, want)
+			break
+		}
+	}
+}
+
+func TestCropRect(t *testing.T) {
+	wide := Picture{Width: 2000, Height: 1000}
+	tall := Picture{Width: 1000, Height: 2000}
+	focused := func(pic Picture, x, y float64) Picture {
+		pic.Focus = &FocalPoint{X: x, Y: y}
+		return pic
+	}
+	tests := []struct {
+		name          string
+		budget        float64
+		pic           Picture
+		width, height float64
+		want          [][]float64
+	}{
+		{"no budget", 0, wide, 150, 100, nil},
+		{"own aspect ratio", 0.15, wide, 200, 100, nil},
+		{"no dimensions", 0.15, Picture{}, 150, 100, nil},
+		{"narrower, centered", 0.15, wide, 150, 100, [][]float64{{250, 0}, {1750, 1000}}},
+		{"narrower, focal point", 0.15, focused(wide, 0.6, 0.5), 150, 100, [][]float64{{450, 0}, {1950, 1000}}},
+		{"narrower, focal point at the edge", 0.15, focused(wide, 0.95, 0.5), 150, 100, [][]float64{{500, 0}, {2000, 1000}}},
+		{"wider, centered", 0.15, tall, 100, 100, [][]float64{{0, 500}, {1000, 1500}}},
+		{"wider, focal point at the top", 0.15, focused(tall, 0.5, 0), 100, 100, [][]float64{{0, 0}, {1000, 1000}}},
+		{"focal box", 0.15, Picture{Width: 2000, Height: 1000, Focus: &FocalPoint{X: 0.5, Box: [][]float64{{0, 0}, {0.4, 1}}}}, 150, 100, [][]float64{{0, 0}, {1500, 1000}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewContinuousLayoutEngineWithConfig(nil, DefaultLayoutConfig())
+			e.logger = discardLogger
+			e.cropBudget = tt.budget
+			if got := e.cropRect(tt.pic, tt.width, tt.height); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("cropRect = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCropBudget(t *testing.T) {
+	const budget = 0.15
+	cfg := DefaultLayoutConfig()
+	cfg.CropBudget = budget
+	var entries []Entry
+	for i, sizes := range [][][2]int{
+		{{4000, 1500}, {1000, 2000}, {1500, 1500}, {3000, 1000}},
+		{{1200, 1600}, {1200, 1600}, {4000, 2000}, {1600, 1200}, {1000, 2200}},
+		{{3000, 1000}, {3000, 1000}, {900, 1600}},
+	} {
+		entry := Entry{ID: int64(i + 1), Time: "2025-03-30 17:50:00"}
+		for j, size := range sizes {
+			entry.Pictures = append(entry.Pictures, Picture{Index: j, Width: size[0], Height: size[1]})
+		}
+		entries = append(entries, entry)
+	}
+	e := NewContinuousLayoutEngineWithConfig(entries, cfg)
+	e.logger = discardLogger
+	pages, err := e.ProcessEntries()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	crops := 0
+	for _, page := range pages {
+		for _, entry := range page.Entries {
+			for _, pic := range entry.Pictures {
+				if pic.Crop == nil {
+					continue
+				}
+				crops++
+				// Placed pictures carry their displayed size, crops are in source pixels.
+				source := entries[entry.ID-1].Pictures[pic.Index]
+				srcW, srcH := float64(source.Width), float64(source.Height)
+				cropW, cropH := pic.Crop[1][0]-pic.Crop[0][0], pic.Crop[1][1]-pic.Crop[0][1]
+				if pic.Crop[0][0] < 0 || pic.Crop[0][1] < 0 || pic.Crop[1][0] > srcW+1e-6 || pic.Crop[1][1] > srcH+1e-6 {
+					t.Errorf("entry %d picture %d: crop %v outside the %dx%d image", entry.ID, pic.Index, pic.Crop, source.Width, source.Height)
+				}
+				if cropW < srcW*(1-2*budget)-1e-6 || cropH < srcH*(1-2*budget)-1e-6 {
+					t.Errorf("entry %d picture %d: crop %v exceeds the budget", entry.ID, pic.Index, pic.Crop)
+				}
+				areaW, areaH := pic.Area[1][0]-pic.Area[0][0], pic.Area[1][1]-pic.Area[0][1]
+				if math.Abs(cropW/cropH-areaW/areaH) > 1e-3*areaW/areaH {
+					t.Errorf("entry %d picture %d: crop %v does not have the aspect ratio of area %v", entry.ID, pic.Index, pic.Crop, pic.Area)
+				}
+			}
+		}
+	}
+	if crops == 0 {
+		t.Error("no picture cropped")
+	}
+}
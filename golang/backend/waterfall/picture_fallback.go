@@ -0,0 +1,186 @@
+package waterfall
+
+import "fmt"
+
+// Relaxation rules recorded when the engine relaxes a constraint to place content.
+const (
+	RelaxFallbackPlacement = "fallback_placement" // placed by the last-resort path in a forced row
+	RelaxMinHeight         = "min_height"         // scaled below the minimum picture height
+)
+
+// Relaxation records a layout constraint that was relaxed so that content could be placed.
+type Relaxation struct {
+	EntryID  int64  `json:"entry_id"`
+	Pictures []int  `json:"pictures"` // Picture.Index of the affected pictures
+	Rule     string `json:"rule"`
+	Detail   string `json:"detail"`
+}
+
+// fallbackRowSize is the number of pictures per row on the last-resort path.
+const fallbackRowSize = 3
+
+// pictureMark remembers where an entry's picture placement started, so the pictures
+// placed since then can be collected.
+type pictureMark struct {
+	page     int // index into e.pages
+	entry    int // index of the entry fragment on that page
+	pictures int // pictures the fragment already held
+}
+
+func (e *ContinuousLayoutEngine) markPictures() pictureMark {
+	mark := pictureMark{page: len(e.pages) - 1, entry: len(e.currentPage.Entries) - 1}
+	if mark.entry >= 0 {
+		mark.pictures = len(e.currentPage.Entries[mark.entry].Pictures)
+	}
+	return mark
+}
+
+// placedSince returns the pictures placed after mark, in page order.
+func (e *ContinuousLayoutEngine) placedSince(mark pictureMark) []Picture {
+	var placed []Picture
+	for p := mark.page; p < len(e.pages); p++ {
+		for i, entry := range e.pages[p].Entries {
+			switch {
+			case p == mark.page && i < mark.entry:
+				continue
+			case p == mark.page && i == mark.entry:
+				placed = append(placed, entry.Pictures[mark.pictures:]...)
+			default:
+				placed = append(placed, entry.Pictures...)
+			}
+		}
+	}
+	return placed
+}
+
+// missingPictures returns the pictures of want that do not appear in placed. Placed
+// pictures carry layout sizes, so they are matched by index and URL.
+func missingPictures(want, placed []Picture) []Picture {
+	type pictureKey struct {
+		index int
+		url   string
+	}
+	counts := make(map[pictureKey]int)
+	for _, pic := range placed {
+		counts[pictureKey{pic.Index, pic.URL}]++
+	}
+	var missing []Picture
+	for _, pic := range want {
+		key := pictureKey{pic.Index, pic.URL}
+		if counts[key] > 0 {
+			counts[key]--
+			continue
+		}
+		missing = append(missing, pic)
+	}
+	return missing
+}
+
+// placeMissingPictures is the last-resort path: every picture the layout strategies
+// failed to place since mark is placed in forced rows, so no input picture is lost.
+// The strategies stop at the first picture they cannot place, so the missing pictures
+// follow the placed ones and the forced rows keep the order of the pictures. cause is
+// why the strategies gave up, if they reported it.
+func (e *ContinuousLayoutEngine) placeMissingPictures(pictures []Picture, mark pictureMark, cause error) {
+	missing := missingPictures(pictures, e.placedSince(mark))
+	if len(missing) == 0 {
+		return
+	}
+	e.warnf("Fallback", "%d of %d pictures were not placed by the layout strategies. Placing them in forced rows.", len(missing), len(pictures))
+	for start := 0; start < len(missing); start += fallbackRowSize {
+		end := start + fallbackRowSize
+		if end > len(missing) {
+			end = len(missing)
+		}
+		e.placeForcedRow(missing[start:end], cause)
+	}
+}
+
+// placeForcedRow places pictures side by side at full width, moving to a new page if
+// less than half the row height is left, and scaling the row down to the page if it
+// is still too tall. Every relaxation is recorded on the page the row lands on, the
+// forced row itself for cause.
+func (e *ContinuousLayoutEngine) placeForcedRow(row []Picture, cause error) {
+	ars := make([]float64, len(row))
+	indices := make([]int, len(row))
+	for i, pic := range row {
+		ars[i] = 1.0
+		if pic.Width > 0 && pic.Height > 0 {
+			ars[i] = float64(pic.Width) / float64(pic.Height)
+		}
+		indices[i] = pic.Index
+	}
+	widths, height, err := calculateRowLayout(ars, e.availableWidth, e.imageSpacing)
+	if err != nil {
+		// Only possible with a page too narrow for the spacing; give each picture an equal share.
+		widths = make([]float64, len(row))
+		for i := range widths {
+			widths[i] = e.availableWidth / float64(len(row))
+		}
+		height = widths[0]
+	}
+
+	spacing := e.requiredSpacingBeforeElement()
+	remaining := e.marginTop + e.availableHeight - e.currentY - spacing
+	if e.currentY > e.marginTop && remaining < height/2 {
+		e.newPage()
+		spacing = 0
+		remaining = e.availableHeight
+	}
+	e.currentY += spacing
+
+	entryID := int64(0)
+	if e.activeEntry != nil {
+		entryID = e.activeEntry.ID
+	}
+	reason := ErrPicturesNotPlaced
+	if cause != nil {
+		reason = fmt.Errorf("%w: %w", ErrPicturesNotPlaced, cause)
+	}
+	e.recordRelaxation(Relaxation{
+		EntryID:  entryID,
+		Pictures: indices,
+		Rule:     RelaxFallbackPlacement,
+		Detail:   fmt.Sprintf("%d picture(s) not placed by the layout strategies were placed in a forced row", len(row)),
+	}, reason)
+
+	if height > remaining {
+		scale := remaining / height
+		for i := range widths {
+			widths[i] *= scale
+		}
+		height = remaining
+	}
+	minHeight := 0.0
+	for _, ar := range ars {
+		if h := GetRequiredMinHeight(e, GetPictureType(ar), len(row)); h > minHeight {
+			minHeight = h
+		}
+	}
+	if height < minHeight {
+		e.recordRelaxation(Relaxation{
+			EntryID:  entryID,
+			Pictures: indices,
+			Rule:     RelaxMinHeight,
+			Detail:   fmt.Sprintf("row height %.0f is below the minimum of %.0f", height, minHeight),
+		}, ErrMinHeightConstraint)
+	}
+
+	layout := TemplateLayout{TotalHeight: height, TotalWidth: e.availableWidth}
+	x := 0.0
+	for i := range row {
+		layout.Positions = append(layout.Positions, []float64{x, 0})
+		layout.Dimensions = append(layout.Dimensions, []float64{widths[i], height})
+		x += widths[i] + e.imageSpacing
+	}
+	e.placePicturesInTemplate(row, layout)
+	e.currentY += height
+}
+
+// recordRelaxation attaches r to the current page and traces it. In strict mode it
+// fails the layout with reason.
+func (e *ContinuousLayoutEngine) recordRelaxation(r Relaxation, reason error) {
+	e.currentPage.Relaxations = append(e.currentPage.Relaxations, r)
+	e.traceDecision(TraceDecision{Kind: TraceRelaxation, Rule: r.Rule, Detail: r.Detail, Pictures: r.Pictures})
+	e.degrade(r.Pictures, fmt.Errorf("%s: %w", r.Detail, reason))
+}
@@ -0,0 +1,106 @@
+package waterfall
+
+import (
+	"fmt"
+	"testing"
+)
+
+// fallbackPictures returns n landscape pictures, except for portrait ones from index
+// first up to last, which no page can hold once portrait minimums are out of reach.
+func fallbackPictures(n, first, last int) []Picture {
+	pictures := make([]Picture, n)
+	for i := range pictures {
+		pictures[i] = Picture{Index: i, URL: fmt.Sprintf("p%d.jpg", i), Width: 1600, Height: 1200}
+		if i >= first && i < last {
+			pictures[i].Width, pictures[i].Height = 1200, 1600
+		}
+	}
+	return pictures
+}
+
+// unplaceableConfig keeps portrait pictures, and landscape ones too if all is set, from
+// fitting any page, so the split rules fail on fresh pages.
+func unplaceableConfig(all bool) LayoutConfig {
+	cfg := DefaultLayoutConfig()
+	cfg.MinPortraitHeights = []float64{1e5, 1e5, 1e5, 1e5, 1e5, 1e5, 1e5, 1e5, 1e5}
+	if all {
+		cfg.MinLandscapeHeights = cfg.MinPortraitHeights
+	}
+	return cfg
+}
+
+// placedIndices returns the Picture.Index of every picture placed for entry id, in
+// page order.
+func placedIndices(pages []ContinuousLayoutPage, id int64) []int {
+	var indices []int
+	for _, page := range pages {
+		for _, entry := range page.Entries {
+			if entry.ID != id {
+				continue
+			}
+			for _, pic := range entry.Pictures {
+				indices = append(indices, pic.Index)
+			}
+		}
+	}
+	return indices
+}
+
+func TestFallbackKeepsPictureOrder(t *testing.T) {
+	tests := []struct {
+		name        string
+		n           int
+		first, last int // portrait pictures
+		all         bool
+	}{
+		// No group fits a fresh page (Rule 4 onwards).
+		{"4 none fit", 4, 0, 0, true},
+		{"6 none fit", 6, 0, 0, true},
+		{"9 none fit", 9, 0, 0, true},
+		// One group of three portraits fails on a fresh page while the next would fit.
+		{"9 first group", 9, 0, 3, false},
+		{"9 middle group", 9, 3, 6, false},
+		{"8 middle group", 8, 2, 5, false},
+		{"14 first set", 14, 4, 7, false},
+		// The last group fails on a fresh page (Rules 5 and 7).
+		{"5 last group", 5, 2, 5, false},
+		{"7 last group", 7, 4, 7, false},
+		{"9 last group", 9, 6, 9, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := []Entry{
+				{ID: 1, Time: "2025年3月30日 17:50", Text: "第一条"},
+				{ID: 2, Time: "2025年3月30日 18:00", Text: "第二条", Pictures: fallbackPictures(tt.n, tt.first, tt.last)},
+			}
+			e := NewContinuousLayoutEngineWithConfig(entries, unplaceableConfig(tt.all))
+			e.logger = discardLogger
+			pages, err := e.ProcessEntries()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := placedIndices(pages, 2)
+			if len(got) != tt.n {
+				t.Fatalf("placed pictures %v, want %d", got, tt.n)
+			}
+			for i, index := range got {
+				if index != i {
+					t.Fatalf("placed pictures %v out of order", got)
+				}
+			}
+
+			fallbacks := 0
+			for _, page := range pages {
+				for _, r := range page.Relaxations {
+					if r.Rule == RelaxFallbackPlacement {
+						fallbacks++
+					}
+				}
+			}
+			if fallbacks == 0 {
+				t.Error("no fallback placement recorded")
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package waterfall
+
+import (
+	"image"
+	_ "image/gif" // decoders for loadImageFromDir
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+
+	_ "golang.org/x/image/webp"
+)
+
+// FocalPoint marks the subject of a picture in coordinates normalized to the image
+// size (0,0 top-left, 1,1 bottom-right). Crops are centered on the center of Box
+// when it is set, and on X/Y otherwise.
+type FocalPoint struct {
+	X   float64     `json:"x"`
+	Y   float64     `json:"y"`
+	Box [][]float64 `json:"box,omitempty"` // 主体区域 [[x0, y0], [x1, y1]]，归一化坐标
+}
+
+// center returns the point crops are centered on, clamped to the image.
+func (f FocalPoint) center() (float64, float64) {
+	x, y := f.X, f.Y
+	if len(f.Box) == 2 && len(f.Box[0]) == 2 && len(f.Box[1]) == 2 {
+		x, y = (f.Box[0][0]+f.Box[1][0])/2, (f.Box[0][1]+f.Box[1][1])/2
+	}
+	clamp := func(v float64) float64 { return math.Max(0, math.Min(1, v)) }
+	return clamp(x), clamp(y)
+}
+
+// ImageLoader returns the pixels of a picture, for focal point detection.
+type ImageLoader func(pic Picture) (image.Image, error)
+
+// saliencyGridSize is the number of cells along the longer side of the grid the
+// saliency heuristic works on.
+const saliencyGridSize = 64
+
+// DetectFocus estimates the subject of img from its edges: the image is reduced to a
+// grid of mean luminances, and the cells whose gradient is above average pull the
+// focal point towards them in proportion to the excess. Box spans one standard
+// deviation of that weight around the point. Flat images get the center.
+func DetectFocus(img image.Image) FocalPoint {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w <= 0 || h <= 0 {
+		return FocalPoint{X: 0.5, Y: 0.5}
+	}
+	cols, rows := saliencyGridSize, saliencyGridSize
+	if w > h {
+		rows = max(1, int(math.Round(float64(saliencyGridSize*h)/float64(w))))
+	} else {
+		cols = max(1, int(math.Round(float64(saliencyGridSize*w)/float64(h))))
+	}
+	cols, rows = min(cols, w), min(rows, h)
+
+	// Mean luminance per cell, sampling at most 4×4 pixels of each.
+	lum := make([][]float64, rows)
+	for r := range lum {
+		lum[r] = make([]float64, cols)
+		y0, y1 := bounds.Min.Y+r*h/rows, bounds.Min.Y+(r+1)*h/rows
+		for c := range lum[r] {
+			x0, x1 := bounds.Min.X+c*w/cols, bounds.Min.X+(c+1)*w/cols
+			stepX, stepY := max(1, (x1-x0)/4), max(1, (y1-y0)/4)
+			total, n := 0.0, 0
+			for y := y0; y < y1; y += stepY {
+				for x := x0; x < x1; x += stepX {
+					red, green, blue, _ := img.At(x, y).RGBA()
+					total += 0.299*float64(red) + 0.587*float64(green) + 0.114*float64(blue)
+					n++
+				}
+			}
+			lum[r][c] = total / float64(n) / 0xffff
+		}
+	}
+
+	at := func(r, c int) float64 { return lum[min(max(r, 0), rows-1)][min(max(c, 0), cols-1)] }
+	gradient := make([][]float64, rows)
+	mean := 0.0
+	for r := range gradient {
+		gradient[r] = make([]float64, cols)
+		for c := range gradient[r] {
+			gradient[r][c] = math.Abs(at(r, c+1)-at(r, c-1)) + math.Abs(at(r+1, c)-at(r-1, c))
+			mean += gradient[r][c]
+		}
+	}
+	mean /= float64(rows * cols)
+
+	var weight, sumX, sumY, sumXX, sumYY float64
+	for r := range gradient {
+		for c, g := range gradient[r] {
+			if g <= mean {
+				continue
+			}
+			x, y := (float64(c)+0.5)/float64(cols), (float64(r)+0.5)/float64(rows)
+			excess := g - mean
+			weight += excess
+			sumX += excess * x
+			sumY += excess * y
+			sumXX += excess * x * x
+			sumYY += excess * y * y
+		}
+	}
+	if weight <= 1e-9 {
+		return FocalPoint{X: 0.5, Y: 0.5}
+	}
+	x, y := sumX/weight, sumY/weight
+	sdX := math.Sqrt(math.Max(0, sumXX/weight-x*x))
+	sdY := math.Sqrt(math.Max(0, sumYY/weight-y*y))
+	return FocalPoint{
+		X: x,
+		Y: y,
+		Box: [][]float64{
+			{math.Max(0, x-sdX), math.Max(0, y-sdY)},
+			{math.Min(1, x+sdX), math.Min(1, y+sdY)},
+		},
+	}
+}
+
+// loadImageFromDir returns a loader reading pictures from dir by the base name of
+// their URL, e.g. https://cdn.example.com/a/b.jpg?x=1 -> dir/b.jpg.
+func loadImageFromDir(dir string) ImageLoader {
+	return func(pic Picture) (image.Image, error) {
+		name := pic.URL
+		if u, err := url.Parse(pic.URL); err == nil {
+			name = u.Path
+		}
+		f, err := os.Open(filepath.Join(dir, path.Base(name)))
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		img, _, err := image.Decode(f)
+		return img, err
+	}
+}
+
+// pictureFocus returns the focal point of pic: the one supplied with the picture, or
+// one detected from its image when an image loader is configured. Detection results
+// are cached by URL. It returns nil when neither is available.
+func (e *ContinuousLayoutEngine) pictureFocus(pic Picture) *FocalPoint {
+	if pic.Focus != nil {
+		return pic.Focus
+	}
+	if e.imageLoader == nil || pic.URL == "" {
+		return nil
+	}
+	if focus, ok := e.focusCache[pic.URL]; ok {
+		return focus
+	}
+	var focus *FocalPoint
+	if img, err := e.imageLoader(pic); err == nil {
+		detected := DetectFocus(img)
+		focus = &detected
+	} else {
+		e.warnf("pictureFocus", "cannot load picture %s for focal point detection: %v", pic.URL, err)
+	}
+	if e.focusCache == nil {
+		e.focusCache = make(map[string]*FocalPoint)
+	}
+	e.focusCache[pic.URL] = focus
+	return focus
+}
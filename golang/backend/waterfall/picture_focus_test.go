@@ -0,0 +1,91 @@
+package waterfall
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// focusImage returns a w × h gray image with a white square of side size at x, y.
+func focusImage(w, h, x, y, size int) image.Image {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for py := 0; py < h; py++ {
+		for px := 0; px < w; px++ {
+			img.SetGray(px, py, color.Gray{Y: 60})
+			if px >= x && px < x+size && py >= y && py < y+size {
+				img.SetGray(px, py, color.Gray{Y: 250})
+			}
+		}
+	}
+	return img
+}
+
+func TestDetectFocus(t *testing.T) {
+	tests := []struct {
+		name string
+		img  image.Image
+		x, y float64
+	}{
+		{"flat", focusImage(300, 200, 0, 0, 0), 0.5, 0.5},
+		{"empty", image.NewGray(image.Rect(0, 0, 0, 0)), 0.5, 0.5},
+		{"top left", focusImage(300, 200, 30, 20, 40), 50.0 / 300, 40.0 / 200},
+		{"bottom right", focusImage(200, 400, 140, 300, 40), 160.0 / 200, 320.0 / 400},
+		{"image smaller than the grid", focusImage(40, 30, 25, 15, 10), 30.0 / 40, 20.0 / 30},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			focus := DetectFocus(tt.img)
+			// The grid cells limit the precision to a few percent.
+			if math.Abs(focus.X-tt.x) > 0.06 || math.Abs(focus.Y-tt.y) > 0.06 {
+				t.Errorf("focus at %.3f, %.3f, want %.3f, %.3f", focus.X, focus.Y, tt.x, tt.y)
+			}
+			if box := focus.Box; box != nil {
+				if box[0][0] > focus.X || box[1][0] < focus.X || box[0][1] > focus.Y || box[1][1] < focus.Y {
+					t.Errorf("box %v does not contain the focus %.3f, %.3f", box, focus.X, focus.Y)
+				}
+				if box[0][0] < 0 || box[0][1] < 0 || box[1][0] > 1 || box[1][1] > 1 {
+					t.Errorf("box %v outside the image", box)
+				}
+			}
+		})
+	}
+}
+
+func TestPictureFocus(t *testing.T) {
+	loads := 0
+	e := NewContinuousLayoutEngineWithConfig(nil, DefaultLayoutConfig())
+	e.logger = discardLogger
+	e.SetImageLoader(func(pic Picture) (image.Image, error) {
+		loads++
+		if pic.URL == "missing.jpg" {
+			return nil, errors.New("not found")
+		}
+		return focusImage(300, 200, 30, 20, 40), nil
+	})
+
+	supplied := &FocalPoint{X: 0.9, Y: 0.9}
+	if got := e.pictureFocus(Picture{URL: "a.jpg", Focus: supplied}); got != supplied {
+		t.Errorf("supplied focal point replaced by %v", got)
+	}
+	if loads != 0 {
+		t.Error("picture loaded despite a supplied focal point")
+	}
+
+	for i := 0; i < 2; i++ {
+		if got := e.pictureFocus(Picture{URL: "a.jpg"}); got == nil || got.X > 0.5 {
+			t.Errorf("detected focal point %v, want one on the left", got)
+		}
+		if got := e.pictureFocus(Picture{URL: "missing.jpg"}); got != nil {
+			t.Errorf("focal point %v for a picture that cannot be loaded", got)
+		}
+	}
+	if loads != 2 {
+		t.Errorf("%d loads for two pictures, want detection results cached", loads)
+	}
+
+	if x, y := (FocalPoint{X: 2, Y: -1}).center(); x != 1 || y != 0 {
+		t.Errorf("center of an out-of-range point = %v, %v, want it clamped", x, y)
+	}
+}
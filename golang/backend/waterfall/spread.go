@@ -0,0 +1,45 @@
+package waterfall
+
+// Page sides, used by renderers and exporters to pair facing pages.
+const (
+	PageSideLeft  = "left"  // verso, even page numbers
+	PageSideRight = "right" // recto, odd page numbers
+)
+
+// PageSide returns the side of a bound book the page number falls on.
+// Page 1 is a recto (right-hand page), so odd pages are right and even pages left.
+func PageSide(page int) string {
+	if page%2 == 0 {
+		return PageSideLeft
+	}
+	return PageSideRight
+}
+
+// horizontalMargins returns the left and right margins, in reference pixels, for the
+// given page number. In spread mode the inner (binding) margin faces the spine: it is
+// on the left of a recto and on the right of a verso.
+func (c LayoutConfig) horizontalMargins(page int) (left, right float64) {
+	if !c.Spread {
+		return c.MarginLeft, c.MarginRight
+	}
+	if PageSide(page) == PageSideRight {
+		return c.InnerMargin, c.OuterMargin
+	}
+	return c.OuterMargin, c.InnerMargin
+}
+
+// PadToRecto appends blank filler pages to pages until nextPage, the number the next
+// page will receive, is a recto (odd). It returns the extended pages and the updated
+// next page number. Fillers are marked with IsFiller and carry no entries.
+func PadToRecto(pages []ContinuousLayoutPage, nextPage int) ([]ContinuousLayoutPage, int) {
+	for PageSide(nextPage) != PageSideRight {
+		pages = append(pages, ContinuousLayoutPage{
+			Page:     nextPage,
+			IsFiller: true,
+			Side:     PageSide(nextPage),
+			Entries:  []PageEntry{},
+		})
+		nextPage++
+	}
+	return pages, nextPage
+}
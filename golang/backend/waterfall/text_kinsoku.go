@@ -0,0 +1,148 @@
+package waterfall
+
+import "unicode/utf8"
+
+// LineBreakRules configures line breaking: word wrapping and hyphenation for Latin
+// text, and Chinese line-breaking rules. The character classes are plain strings so
+// the table can be edited in a config file.
+type LineBreakRules struct {
+	WordWrap bool `json:"word_wrap" yaml:"word_wrap"` // 西文只在单词边界断行
+	// HyphenationPatterns is a TeX-style pattern file (e.g. hyph-en-us.pat.txt) used to
+	// hyphenate words that cross the line end. Empty disables hyphenation.
+	HyphenationPatterns string `json:"hyphenation_patterns" yaml:"hyphenation_patterns"`
+
+	Kinsoku                bool `json:"kinsoku" yaml:"kinsoku"`                                 // 避头尾禁则
+	HangingPunctuation     bool `json:"hanging_punctuation" yaml:"hanging_punctuation"`         // 标点悬挂
+	PunctuationCompression bool `json:"punctuation_compression" yaml:"punctuation_compression"` // 标点挤压
+
+	NoLineStart  string `json:"no_line_start" yaml:"no_line_start"` // 不能位于行首的字符
+	NoLineEnd    string `json:"no_line_end" yaml:"no_line_end"`     // 不能位于行尾的字符
+	Hanging      string `json:"hanging" yaml:"hanging"`             // 可悬挂在版心外的行尾标点
+	Compressible string `json:"compressible" yaml:"compressible"`   // 相邻时可挤压为半宽的标点
+}
+
+// DefaultLineBreakRules returns the standard table with word wrapping on and the
+// Chinese rules switched off.
+func DefaultLineBreakRules() LineBreakRules {
+	return LineBreakRules{
+		WordWrap:     true,
+		NoLineStart:  "，。、；：？！）》」』】〕〉”’…—～·%‰,.;:?!)]}",
+		NoLineEnd:    "（《「『【〔〈“‘([{",
+		Hanging:      "，。、,.",
+		Compressible: "，。、；：（）《》「」『』【】〔〕〈〉“”‘’",
+	}
+}
+
+// lineBreaker is the lookup form of LineBreakRules used while wrapping.
+type lineBreaker struct {
+	rules        LineBreakRules
+	noLineStart  map[rune]bool
+	noLineEnd    map[rune]bool
+	hanging      map[rune]bool
+	compressible map[rune]bool
+	hyphenator   *Hyphenator // nil when hyphenation is off
+}
+
+func newLineBreaker(rules LineBreakRules) lineBreaker {
+	set := func(chars string) map[rune]bool {
+		m := make(map[rune]bool)
+		for _, r := range chars {
+			m[r] = true
+		}
+		return m
+	}
+	return lineBreaker{
+		rules:        rules,
+		noLineStart:  set(rules.NoLineStart),
+		noLineEnd:    set(rules.NoLineEnd),
+		hanging:      set(rules.Hanging),
+		compressible: set(rules.Compressible),
+	}
+}
+
+// compress halves the advance of compressible punctuation that is directly followed by
+// another compressible punctuation mark, e.g. "。」" or "）（".
+func (b lineBreaker) compress(runes []rune, widths []float64) {
+	for _, i := range b.compressed(runes) {
+		widths[i] /= 2
+	}
+}
+
+// compressed returns the positions of the runes compress halves, in increasing order.
+func (b lineBreaker) compressed(runes []rune) []int {
+	if !b.rules.PunctuationCompression {
+		return nil
+	}
+	var positions []int
+	for i := 0; i+1 < len(runes); i++ {
+		if b.compressible[runes[i]] && b.compressible[runes[i+1]] {
+			positions = append(positions, i)
+		}
+	}
+	return positions
+}
+
+// compressedPunctuation returns the rune offsets of the compressed punctuation in the
+// text of lines as it appears in Texts: joined by newlines, with inline glyphs
+// expanded to their codes. Compression applies within each line.
+func (e *ContinuousLayoutEngine) compressedPunctuation(lines []string) []int {
+	offsets := []int{}
+	offset := 0
+	for k, line := range lines {
+		if k > 0 {
+			offset++ // the newline
+		}
+		runes := []rune(line)
+		compressed := e.lineBreaker.compressed(runes)
+		for i, r := range runes {
+			if len(compressed) > 0 && compressed[0] == i {
+				offsets = append(offsets, offset)
+				compressed = compressed[1:]
+			}
+			if g, ok := e.inline.glyph(r); ok {
+				offset += utf8.RuneCountInString(g.code)
+			} else {
+				offset++
+			}
+		}
+	}
+	return offsets
+}
+
+// legalBreak reports whether a line may end before runes[i].
+func (b lineBreaker) legalBreak(runes []rune, i int) bool {
+	if i <= 0 || i >= len(runes) {
+		return true
+	}
+	if b.rules.WordWrap && isWordRune(runes[i-1]) && isWordRune(runes[i]) && runes[i-1] != '-' {
+		return false
+	}
+	if !b.rules.Kinsoku {
+		return true
+	}
+	return !b.noLineStart[runes[i]] && !b.noLineEnd[runes[i-1]]
+}
+
+// adjustBreak moves a break that would end the line runes[start:end] to a legal
+// position. end is the first rune that did not fit. Punctuation that may hang is kept
+// on the line past the margin; otherwise runes are pushed to the next line (推出).
+// The line never becomes empty.
+func (b lineBreaker) adjustBreak(runes []rune, start, end int) int {
+	if end >= len(runes) {
+		return end
+	}
+	if b.rules.HangingPunctuation && b.hanging[runes[end]] && b.legalBreak(runes, end+1) {
+		return end + 1
+	}
+	if b.legalBreak(runes, end) {
+		return end
+	}
+	for i := end - 1; i > start; i-- {
+		if b.legalBreak(runes, i) {
+			return i
+		}
+	}
+	// No legal break inside the line (a word or punctuation run longer than the line);
+	// break where it overflowed.
+	return end
+}
@@ -0,0 +1,131 @@
+package waterfall
+
+import (
+	"reflect"
+	"testing"
+)
+
+// kinsokuBreaker returns the default table with the Chinese rules switched on.
+func kinsokuBreaker(hanging, compression bool) lineBreaker {
+	rules := DefaultLineBreakRules()
+	rules.Kinsoku = true
+	rules.HangingPunctuation = hanging
+	rules.PunctuationCompression = compression
+	return newLineBreaker(rules)
+}
+
+func TestLegalBreak(t *testing.T) {
+	b := kinsokuBreaker(false, false)
+	tests := []struct {
+		text string
+		i    int
+		want bool
+	}{
+		{"今天好", 1, true},
+		{"今天。好", 2, false}, // 。 may not start a line
+		{"今（天）", 2, false}, // （ may not end a line
+		{"今天", 0, true},
+		{"今天", 2, true},
+		{"word wrap", 2, false}, // inside a word
+		{"well-known", 5, true}, // after a hyphen
+		{"word wrap", 5, true},
+	}
+	for _, tt := range tests {
+		if got := b.legalBreak([]rune(tt.text), tt.i); got != tt.want {
+			t.Errorf("legalBreak(%q, %d) = %v, want %v", tt.text, tt.i, got, tt.want)
+		}
+	}
+
+	off := newLineBreaker(DefaultLineBreakRules())
+	if !off.legalBreak([]rune("今天。好"), 2) {
+		t.Error("punctuation kept off the line start with kinsoku off")
+	}
+}
+
+func TestAdjustBreak(t *testing.T) {
+	tests := []struct {
+		name       string
+		hanging    bool
+		text       string
+		start, end int
+		want       int
+	}{
+		{"legal break", false, "一二三四五", 0, 3, 3},
+		{"pushed out before a closing mark", false, "一二三。五", 0, 3, 2},
+		{"pushed out after an opening mark", false, "一二（三四", 0, 3, 2},
+		{"hanging comma", true, "一二三，五", 0, 3, 4},
+		{"hanging only for hanging marks", true, "一二三」五", 0, 3, 2},
+		{"no legal break", false, "一。。。。", 0, 3, 3},
+		{"end of text", false, "一二三", 0, 3, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := kinsokuBreaker(tt.hanging, false)
+			if got := b.adjustBreak([]rune(tt.text), tt.start, tt.end); got != tt.want {
+				t.Errorf("adjustBreak(%q, %d, %d) = %d, want %d", tt.text, tt.start, tt.end, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompressed(t *testing.T) {
+	b := kinsokuBreaker(false, true)
+	tests := []struct {
+		text string
+		want []int
+	}{
+		{"他说：「好。」", []int{2, 5}},
+		{"（（好））", []int{0, 3}},
+		{"好。", nil},
+	}
+	for _, tt := range tests {
+		if got := b.compressed([]rune(tt.text)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("compressed(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+	if got := kinsokuBreaker(false, false).compressed([]rune("。」")); got != nil {
+		t.Errorf("compressed with compression off = %v", got)
+	}
+}
+
+func TestCompressedPunctuationOffsets(t *testing.T) {
+	cfg := DefaultLayoutConfig()
+	cfg.LineBreaking.PunctuationCompression = true
+	cfg.Emoticons = inlineTestConfig(t)
+	e := NewContinuousLayoutEngineWithConfig(nil, cfg)
+	e.logger = discardLogger
+
+	// [微笑] counts with its four characters in the joined text, the newline with one.
+	lines := []string{e.inline.substitute("[微笑]好。」"), "（（"}
+	if got, want := e.compressedPunctuation(lines), []int{5, 8}; !reflect.DeepEqual(got, want) {
+		t.Errorf("compressedPunctuation = %v, want %v", got, want)
+	}
+}
+
+func TestWrapParagraphFitsAtFullWidth(t *testing.T) {
+	cfg := DefaultLayoutConfig()
+	cfg.LineBreaking.PunctuationCompression = true
+	e := NewContinuousLayoutEngineWithConfig(nil, cfg)
+	e.logger = discardLogger
+	e.measurer = FixedWidthMeasurer{}
+	e.availableWidth = 3.5 * e.fontSize
+
+	// 。 is compressed before 」, but would end the first line at its full width.
+	lines := e.wrapParagraph("一二三。」四")
+	if want := []string{"一二三", "。」四"}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("wrapParagraph = %q, want %q", lines, want)
+	}
+
+	for _, text := range []string{"他说：「好。」（对）。", "（（好））。。「「是」」"} {
+		for _, line := range e.wrapParagraph(text) {
+			runes := []rune(line)
+			width := float64(len(runes)) * e.fontSize
+			for range e.lineBreaker.compressed(runes) {
+				width -= e.fontSize / 2
+			}
+			if width > e.availableWidth+1e-6 {
+				t.Errorf("line %q of %q is %.2f wide, more than %.2f", line, text, width, e.availableWidth)
+			}
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package waterfall
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// TextMeasurer reports glyph advance widths used for line breaking.
+type TextMeasurer interface {
+	// Advance returns the advance width of r at fontSize, in the unit of fontSize.
+	Advance(r rune, fontSize float64) float64
+}
+
+// measureString returns the total advance width of s.
+func measureString(m TextMeasurer, s string, fontSize float64) float64 {
+	width := 0.0
+	for _, r := range s {
+		width += m.Advance(r, fontSize)
+	}
+	return width
+}
+
+// FixedWidthMeasurer treats every rune as one em wide. It is the measurer used when no
+// font file is configured and reproduces the engine's original characters-per-line estimate.
+type FixedWidthMeasurer struct{}
+
+// Advance implements TextMeasurer.
+func (FixedWidthMeasurer) Advance(r rune, fontSize float64) float64 {
+	return fontSize
+}
+
+// FontMeasurer measures text with the metrics of a TrueType/OpenType font.
+// It is safe for concurrent use.
+type FontMeasurer struct {
+	font       *sfnt.Font
+	unitsPerEm float64
+
+	mu       sync.Mutex
+	buf      sfnt.Buffer
+	advances map[rune]float64 // advance in em, cached per rune
+}
+
+// LoadFontMeasurer loads a .ttf, .otf or .ttc/.otc font file. index selects the face
+// inside a collection and must be 0 for single fonts.
+func LoadFontMeasurer(path string, index int) (*FontMeasurer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var f *sfnt.Font
+	if bytes.HasPrefix(data, []byte("ttcf")) {
+		collection, err := sfnt.ParseCollection(data)
+		if err != nil {
+			return nil, fmt.Errorf("font %s: %w", path, err)
+		}
+		if index < 0 || index >= collection.NumFonts() {
+			return nil, fmt.Errorf("font %s: index %d out of range (collection has %d fonts)", path, index, collection.NumFonts())
+		}
+		if f, err = collection.Font(index); err != nil {
+			return nil, fmt.Errorf("font %s: %w", path, err)
+		}
+	} else {
+		if index != 0 {
+			return nil, fmt.Errorf("font %s: index %d given for a single font file", path, index)
+		}
+		if f, err = sfnt.Parse(data); err != nil {
+			return nil, fmt.Errorf("font %s: %w", path, err)
+		}
+	}
+
+	return &FontMeasurer{
+		font:       f,
+		unitsPerEm: float64(f.UnitsPerEm()),
+		advances:   make(map[rune]float64),
+	}, nil
+}
+
+// Advance implements TextMeasurer. Runes missing from the font are measured as one em,
+// the width of a CJK ideograph.
+func (m *FontMeasurer) Advance(r rune, fontSize float64) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	em, ok := m.advances[r]
+	if !ok {
+		em = 1
+		if idx, err := m.font.GlyphIndex(&m.buf, r); err == nil && idx != 0 {
+			// Request the advance at ppem = unitsPerEm so the result is in font units.
+			ppem := fixed.Int26_6(m.unitsPerEm * 64)
+			if adv, err := m.font.GlyphAdvance(&m.buf, idx, ppem, font.HintingNone); err == nil {
+				em = float64(adv) / 64 / m.unitsPerEm
+			}
+		}
+		m.advances[r] = em
+	}
+	return em * fontSize
+}
+
+// fontMeasurers caches loaded fonts by file and face index, so engines created per
+// request share one parsed font.
+var fontMeasurers sync.Map // fontKey -> *FontMeasurer
+
+type fontKey struct {
+	path  string
+	index int
+}
+
+// cachedFontMeasurer returns the measurer for path and index, loading it once.
+func cachedFontMeasurer(path string, index int) (*FontMeasurer, error) {
+	key := fontKey{path, index}
+	if m, ok := fontMeasurers.Load(key); ok {
+		return m.(*FontMeasurer), nil
+	}
+	m, err := LoadFontMeasurer(path, index)
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := fontMeasurers.LoadOrStore(key, m)
+	return actual.(*FontMeasurer), nil
+}
+
+// textMeasurer returns the measurer selected by the config: the configured font, or
+// FixedWidthMeasurer when no font file is set.
+func (c LayoutConfig) textMeasurer() (TextMeasurer, error) {
+	if c.FontFile == "" {
+		return FixedWidthMeasurer{}, nil
+	}
+	return cachedFontMeasurer(c.FontFile, c.FontIndex)
+}
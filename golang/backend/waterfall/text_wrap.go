@@ -0,0 +1,148 @@
+package waterfall
+
+import (
+	"strings"
+	"unicode"
+)
+
+// wrapText breaks text into lines that fit availableWidth at the engine font size.
+// Explicit newlines start a new paragraph; empty paragraphs are kept as empty lines.
+// paragraphs[i] is the index of the paragraph line i belongs to.
+func (e *ContinuousLayoutEngine) wrapText(text string) (lines []string, paragraphs []int) {
+	for p, paragraph := range strings.Split(text, "\n") {
+		for _, line := range e.wrapParagraph(paragraph) {
+			lines = append(lines, line)
+			paragraphs = append(paragraphs, p)
+		}
+	}
+	return lines, paragraphs
+}
+
+// keepLines adjusts n, the number of lines from lines[start:] that fit on the current
+// page, so a paragraph broken across the page keeps at least config.Orphans lines at
+// the bottom of this page and config.Widows lines at the top of the next. It returns 0
+// when the break has to move before line start, i.e. the text should begin on a new
+// page. On a page with no content yet the rules are not applied, as moving the text
+// would not help.
+func (e *ContinuousLayoutEngine) keepLines(paragraphs []int, start, n int, freshPage bool) int {
+	orphans, widows := e.config.Orphans, e.config.Widows
+	breakAt := start + n
+	if breakAt >= len(paragraphs) || breakAt == 0 || paragraphs[breakAt-1] != paragraphs[breakAt] {
+		return n // no break, or the break falls between paragraphs
+	}
+
+	paragraph := paragraphs[breakAt]
+	first, end := breakAt, breakAt
+	for first > 0 && paragraphs[first-1] == paragraph {
+		first--
+	}
+	for end < len(paragraphs) && paragraphs[end] == paragraph {
+		end++
+	}
+
+	if end-breakAt < widows {
+		breakAt = end - widows
+	}
+	if breakAt-first < orphans {
+		breakAt = first
+	}
+	if breakAt <= start {
+		if freshPage {
+			return n
+		}
+		return 0
+	}
+	return breakAt - start
+}
+
+// wrapParagraph breaks a single paragraph greedily, measuring each rune with the
+// engine's TextMeasurer and applying the configured line-breaking rules. Spaces at a
+// line break are dropped, and a Latin word crossing the line end is hyphenated when
+// hyphenation patterns are configured.
+func (e *ContinuousLayoutEngine) wrapParagraph(paragraph string) []string {
+	if paragraph == "" {
+		return []string{""}
+	}
+
+	runes := []rune(paragraph)
+	widths := make([]float64, len(runes))
+	for i, r := range runes {
+		widths[i] = e.advance(r)
+	}
+	// A compressed mark ending a line is set at full width, as compression only
+	// applies within a line, so every rune must fit at its full width.
+	full := append([]float64(nil), widths...)
+	e.lineBreaker.compress(runes, widths)
+
+	// Small tolerance so a line that fits exactly is not broken by rounding.
+	maxWidth := e.availableWidth + 1e-6
+	var lines []string
+	for start := 0; start < len(runes); {
+		end, width := start, 0.0
+		for end < len(runes) && (end == start || width+full[end] <= maxWidth) {
+			width += widths[end]
+			end++
+		}
+
+		if end < len(runes) && !isBreakSpace(runes[end]) {
+			if split, ok := e.hyphenateAt(runes, widths, start, end, maxWidth); ok {
+				lines = append(lines, string(runes[start:split])+"-")
+				start = split
+				continue
+			}
+			end = e.lineBreaker.adjustBreak(runes, start, end)
+		}
+
+		lines = append(lines, strings.TrimRightFunc(string(runes[start:end]), isBreakSpace))
+		start = end
+		for start < len(runes) && isBreakSpace(runes[start]) {
+			start++
+		}
+	}
+	return lines
+}
+
+// hyphenateAt tries to split the word that crosses the line end at end. It returns
+// the rune index to split at, choosing the last hyphenation point for which the
+// line plus a hyphen still fits.
+func (e *ContinuousLayoutEngine) hyphenateAt(runes []rune, widths []float64, start, end int, maxWidth float64) (int, bool) {
+	if e.lineBreaker.hyphenator == nil || !isWordRune(runes[end]) || !isWordRune(runes[end-1]) {
+		return 0, false
+	}
+
+	// Find the letters of the word around the overflow point; surrounding
+	// punctuation (quotes, commas) stays attached but is not hyphenated.
+	wordStart, wordEnd := end, end
+	for wordStart > start && unicode.IsLetter(runes[wordStart-1]) {
+		wordStart--
+	}
+	for wordEnd < len(runes) && unicode.IsLetter(runes[wordEnd]) {
+		wordEnd++
+	}
+	if wordEnd-wordStart < hyphenLeftMin+hyphenRightMin || !unicode.IsLetter(runes[end]) {
+		return 0, false
+	}
+
+	hyphenWidth := e.measurer.Advance('-', e.fontSize)
+	points := e.lineBreaker.hyphenator.Hyphenate(string(runes[wordStart:wordEnd]))
+	for i := len(points) - 1; i >= 0; i-- {
+		split := wordStart + points[i]
+		if split <= start || split > end {
+			continue
+		}
+		width := hyphenWidth
+		for _, w := range widths[start:split] {
+			width += w
+		}
+		if width <= maxWidth {
+			return split, true
+		}
+	}
+	return 0, false
+}
+
+// isBreakSpace reports whether r is a space that may be dropped at a line break.
+// The ideographic space (U+3000) is kept, as it is used for indentation.
+func isBreakSpace(r rune) bool {
+	return r == ' ' || r == '\t'
+}
@@ -0,0 +1,35 @@
+package waterfall
+
+import "testing"
+
+func TestKeepLines(t *testing.T) {
+	// Lines 0-1 are paragraph 0, lines 2-7 paragraph 1 and line 8 paragraph 2.
+	paragraphs := []int{0, 0, 1, 1, 1, 1, 1, 1, 2}
+	tests := []struct {
+		name            string
+		orphans, widows int
+		start, n        int
+		fresh           bool
+		want            int
+	}{
+		{"break between paragraphs", 2, 2, 0, 2, false, 2},
+		{"all lines fit", 2, 2, 0, 9, false, 9},
+		{"break within the rules", 2, 2, 0, 5, false, 5},
+		{"widow pulls lines over", 2, 2, 0, 7, false, 6},
+		{"orphan moves the paragraph", 2, 2, 0, 3, false, 2},
+		{"orphan at the text start", 2, 2, 2, 1, false, 0},
+		{"orphan at the top of a fresh page", 2, 2, 2, 1, true, 1},
+		{"widow and orphan rules of 3", 3, 3, 0, 4, false, 2},
+		{"rules off", 1, 1, 0, 7, false, 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultLayoutConfig()
+			cfg.Orphans, cfg.Widows = tt.orphans, tt.widows
+			e := NewContinuousLayoutEngineWithConfig(nil, cfg)
+			if got := e.keepLines(paragraphs, tt.start, tt.n, tt.fresh); got != tt.want {
+				t.Errorf("keepLines(start %d, n %d) = %d, want %d", tt.start, tt.n, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package waterfall
+
+import "fmt"
+
+// Kinds of traced layout decisions.
+const (
+	TraceTemplateChoice = "template_choice" // template candidates rated for a picture group
+	TraceRule           = "rule"            // a split or keep-together rule was applied
+	TracePlacement      = "placement"       // pictures were placed on the page
+	TracePageBreak      = "page_break"      // a new page was started
+	TraceRelaxation     = "relaxation"      // a constraint was relaxed, see Relaxation
+)
+
+// LayoutTrace records the decisions taken while laying out entries, for finding out
+// why content was placed the way it was. It is only collected when the config's trace
+// option is on. Trial layouts, such as those of the pagination optimizer, are not
+// traced.
+type LayoutTrace struct {
+	// PageBreaks lists the IDs of the entries the pagination optimizer starts on a
+	// new page.
+	PageBreaks []int64      `json:"page_breaks,omitempty"`
+	Entries    []EntryTrace `json:"entries"`
+}
+
+// EntryTrace lists the decisions taken for one entry, in order.
+type EntryTrace struct {
+	EntryID   int64           `json:"entry_id"`
+	Decisions []TraceDecision `json:"decisions"`
+}
+
+// TraceDecision is one layout decision. Lengths are in layout pixels.
+type TraceDecision struct {
+	Kind     string          `json:"kind"`
+	Page     int             `json:"page"`
+	Source   string          `json:"source,omitempty"`   // function that took the decision
+	Rule     string          `json:"rule,omitempty"`     // e.g. "Rule 3" of the split rules
+	Detail   string          `json:"detail,omitempty"`   // what the rule did or why the page broke
+	Pictures []int           `json:"pictures,omitempty"` // Picture.Index of the affected pictures
+	Height   float64         `json:"height,omitempty"`   // height of the placed block
+	Scale    float64         `json:"scale,omitempty"`    // share of the full width a placed template block kept
+	Choice   *TemplateChoice `json:"choice,omitempty"`   // candidates with scales, violation factors and scores
+}
+
+// Trace returns the decisions recorded by ProcessEntries, or nil when the config's
+// trace option is off.
+func (e *ContinuousLayoutEngine) Trace() *LayoutTrace {
+	return e.trace
+}
+
+// traceEntry starts the trace of entry.
+func (e *ContinuousLayoutEngine) traceEntry(entry Entry) {
+	if e.trace == nil {
+		return
+	}
+	e.trace.Entries = append(e.trace.Entries, EntryTrace{EntryID: entry.ID, Decisions: []TraceDecision{}})
+}
+
+// traceDecision records d for the entry being laid out, on the current page.
+func (e *ContinuousLayoutEngine) traceDecision(d TraceDecision) {
+	if e.trace == nil || len(e.trace.Entries) == 0 {
+		return
+	}
+	if e.currentPage != nil {
+		d.Page = e.currentPage.Page
+	}
+	entry := &e.trace.Entries[len(e.trace.Entries)-1]
+	entry.Decisions = append(entry.Decisions, d)
+}
+
+// traceRule traces that rule of source was applied, as described by detail.
+func (e *ContinuousLayoutEngine) traceRule(source, rule, detail string) {
+	e.traceDecision(TraceDecision{Kind: TraceRule, Source: source, Rule: rule, Detail: detail})
+}
+
+// ruleApplied logs and traces that rule of source placed content, as described by
+// format and args.
+func (e *ContinuousLayoutEngine) ruleApplied(source, rule, format string, args ...any) {
+	e.debugf(source, rule+" - Success. "+format, args...)
+	if e.trace != nil {
+		e.traceRule(source, rule, fmt.Sprintf(format, args...))
+	}
+}
+
+// tracePlacement traces that pictures were placed in a block of the given height.
+// scale is the share of the full width kept by a template block, 0 for other blocks.
+func (e *ContinuousLayoutEngine) tracePlacement(source string, pictures []Picture, height, scale float64) {
+	if e.trace == nil {
+		return
+	}
+	e.traceDecision(TraceDecision{Kind: TracePlacement, Source: source, Pictures: pictureIndices(pictures), Height: height, Scale: scale})
+}
+
+// pictureIndices returns the Picture.Index of every picture.
+func pictureIndices(pictures []Picture) []int {
+	indices := make([]int, len(pictures))
+	for i, pic := range pictures {
+		indices[i] = pic.Index
+	}
+	return indices
+}
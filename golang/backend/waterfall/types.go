@@ -1,5 +1,7 @@
 package waterfall
 
+import "log/slog"
+
 // Picture represents a picture in the layout
 type Picture struct {
 	Index  int         `json:"index"`
@@ -7,6 +9,12 @@ type Picture struct {
 	URL    string      `json:"url"`
 	Width  int         `json:"width"`
 	Height int         `json:"height"`
+	// Crop is the region of the source image shown in Area, [[x0, y0], [x1, y1]] in
+	// source pixels. It is only set when the picture was cropped to fit its template.
+	Crop [][]float64 `json:"crop,omitempty"`
+	// Focus is the subject of the picture, which crops are centered on. Importers may
+	// set it; otherwise it is detected from the image when image_dir is configured.
+	Focus *FocalPoint `json:"focus,omitempty"`
 }
 
 // Entry represents a single moment entry with time, text and pictures
@@ -26,22 +34,44 @@ type PageEntry struct {
 	TimeArea  [][]float64   `json:"time_area"`
 	TextAreas [][][]float64 `json:"text_areas"`
 	Texts     []string      `json:"texts"`
-	Pictures  []Picture     `json:"pictures"`
+	// InlineRuns holds the text lines split into text and inline image runs, in
+	// reading order. It is only set when emoticon images are enabled.
+	InlineRuns []InlineRun `json:"inline_runs,omitempty"`
+	// CompressedPunctuation lists for each string in Texts the rune offsets of the
+	// punctuation set at half its width by punctuation_compression. Renderers must
+	// compress these marks too, or lines overflow the text area. It is only set when
+	// punctuation compression is on.
+	CompressedPunctuation [][]int   `json:"compressed_punctuation,omitempty"`
+	Pictures              []Picture `json:"pictures"`
+
+	IsContinuation      bool `json:"is_continuation"`        // 是否是上一页条目的续接部分
+	ContinuesOnNextPage bool `json:"continues_on_next_page"` // 条目是否在下一页继续
 }
 
 // ContinuousLayoutPage represents a single page in the continuous layout
 type ContinuousLayoutPage struct {
 	Page      int         `json:"page"`
 	IsInsert  bool        `json:"is_insert"`  // 是否是插页
+	IsFiller  bool        `json:"is_filler"`  // 是否是为对齐右页而插入的空白页
+	Side      string      `json:"side"`       // 左页或右页：left / right
 	YearMonth string      `json:"year_month"` // 年月信息，格式：2025年3月
 	Entries   []PageEntry `json:"entries"`
+	// Relaxations lists the constraints relaxed to place content on this page.
+	Relaxations []Relaxation `json:"relaxations,omitempty"`
+	// TemplateChoices lists the rated template candidates when expose_scores is on.
+	TemplateChoices []TemplateChoice `json:"template_choices,omitempty"`
 }
 
 // ContinuousLayoutEngine represents the continuous layout engine
 type ContinuousLayoutEngine struct {
 	entries             []Entry
+	config              LayoutConfig
+	dpiScale            float64 // converts config lengths to layout pixels
 	pages               []ContinuousLayoutPage
 	currentPage         *ContinuousLayoutPage
+	firstPageNumber     int // page number of the first page, decides left/right sides
+	pageWidth           float64
+	pageHeight          float64
 	marginLeft          float64
 	marginRight         float64
 	marginTop           float64
@@ -50,6 +80,9 @@ type ContinuousLayoutEngine struct {
 	availableHeight     float64
 	timeHeight          float64
 	fontSize            float64
+	measurer            TextMeasurer  // measures text for line breaking
+	lineBreaker         lineBreaker   // CJK line-breaking rules from the config
+	inline              *inlineImages // emoticon/emoji glyphs; nil when disabled
 	lineHeight          float64
 	currentY            float64
 	timeAreaBottom      float64
@@ -63,6 +96,22 @@ type ContinuousLayoutEngine struct {
 	singleImageHeight   float64   // 单张竖图的默认高度
 	singleImageWidth    float64   // 单张横图的默认宽度
 	currentYearMonth    string
+	activeEntry         *Entry // entry being laid out; new pages continue it
+	activeEntryStarted  bool   // whether the active entry's time block has been placed
+
+	justifiedRowHeight float64       // 等高行布局的目标行高
+	scaleLoss          float64       // sum of 1 - scale over the template blocks placed scaled down
+	pictureChoice      pictureChoice // placement of the active entry's pictures chosen by the pagination optimizer
+
+	templates         []LayoutTemplate       // templates from the config's template_dir
+	scorer            LayoutScorer           // rates template candidates
+	cropBudget        float64                // share of a picture that may be cropped per side
+	imageLoader       ImageLoader            // reads pictures for focal point detection
+	focusCache        map[string]*FocalPoint // detected focal points by URL, nil if unreadable
+	disabledTemplates map[string]bool        // layout templates switched off in the config
+	logger            *slog.Logger           // receives diagnostics; trial layouts discard them
+	trace             *LayoutTrace           // decisions recorded when the config's trace option is on
+	strictErr         error                  // first degradation in strict mode, see degrade
 }
 
 // TemplateLayout holds the calculated positions and dimensions for a template
@@ -43,21 +43,59 @@ func GetRequiredMinHeight(e *ContinuousLayoutEngine, picType string, numPics int
 		if len(e.minLandscapeHeights) > idx-1 {
 			return e.minLandscapeHeights[idx-1]
 		}
-		fmt.Printf("Warning: minLandscapeHeights not properly initialized or index out of bounds (%d)\n", idx)
-		return 800.0 // Return default landscape height as fallback
+		e.warnf("GetRequiredMinHeight", "minLandscapeHeights not properly initialized or index out of bounds (%d)", idx)
+		return 800.0 * e.dpiScale // Return default landscape height as fallback
 	case "portrait":
 		// Make sure the slice has been initialized and the index is valid
 		if len(e.minPortraitHeights) > idx-1 {
 			return e.minPortraitHeights[idx-1]
 		}
-		fmt.Printf("Warning: minPortraitHeights not properly initialized or index out of bounds (%d)\n", idx)
-		return 1000.0 // Return default portrait height as fallback
+		e.warnf("GetRequiredMinHeight", "minPortraitHeights not properly initialized or index out of bounds (%d)", idx)
+		return 1000.0 * e.dpiScale // Return default portrait height as fallback
 	default: // square, unknown
 		// Use landscape height as fallback
 		if len(e.minLandscapeHeights) > idx-1 {
 			return e.minLandscapeHeights[idx-1]
 		}
-		fmt.Printf("Warning: Fallback minLandscapeHeights not properly initialized or index out of bounds (%d)\n", idx)
-		return 800.0 // Return default landscape height as fallback
+		e.warnf("GetRequiredMinHeight", "Fallback minLandscapeHeights not properly initialized or index out of bounds (%d)", idx)
+		return 800.0 * e.dpiScale // Return default landscape height as fallback
 	}
 }
+
+// calculateRowLayout fits pictures side by side in one row of width AW, all at the same height.
+func calculateRowLayout(ARs []float64, AW, spacing float64) (widths []float64, height float64, err error) {
+	numPicsInRow := len(ARs)
+	if numPicsInRow < 1 {
+		return nil, 0, fmt.Errorf("cannot calculate row layout with zero pictures")
+	}
+
+	totalSpacing := float64(numPicsInRow-1) * spacing
+	rowAvailableWidth := AW - totalSpacing
+	if rowAvailableWidth <= 1e-6 {
+		return nil, 0, fmt.Errorf("row available width (%.2f) is too small", rowAvailableWidth)
+	}
+
+	totalARSum := 0.0
+	for _, ar := range ARs {
+		if ar <= 1e-6 {
+			return nil, 0, fmt.Errorf("invalid aspect ratio (%.2f) encountered in row calculation", ar)
+		}
+		totalARSum += ar
+	}
+
+	if totalARSum <= 1e-6 {
+		return nil, 0, fmt.Errorf("total aspect ratio sum (%.2f) is too small for row calculation", totalARSum)
+	}
+
+	height = rowAvailableWidth / totalARSum
+	if height <= 1e-6 {
+		return nil, 0, fmt.Errorf("calculated row height (%.2f) is too small", height)
+	}
+
+	widths = make([]float64, numPicsInRow)
+	for i, ar := range ARs {
+		widths[i] = height * ar
+	}
+
+	return widths, height, nil
+}
@@ -0,0 +1,148 @@
+package waterfall
+
+import (
+	"math"
+	"sort"
+)
+
+// VerticalJustification spreads the space left at the bottom of a page over the gaps
+// between entries and between the elements of an entry (time, text, pictures).
+// The last page of every month is left as it is.
+type VerticalJustification struct {
+	Enabled bool `json:"enabled" yaml:"enabled"`
+	// MaxEntrySpacing and MaxElementSpacing are the largest the gaps may grow to,
+	// in pixels at 300 DPI like entry_spacing and element_spacing.
+	MaxEntrySpacing   float64 `json:"max_entry_spacing" yaml:"max_entry_spacing"`
+	MaxElementSpacing float64 `json:"max_element_spacing" yaml:"max_element_spacing"`
+}
+
+// DefaultVerticalJustification returns the settings with justification switched off
+// and gaps allowed to grow to twice the default spacing.
+func DefaultVerticalJustification() VerticalJustification {
+	return VerticalJustification{
+		MaxEntrySpacing:   300,
+		MaxElementSpacing: 60,
+	}
+}
+
+// pageBlock is a run of vertically adjacent areas of one entry: a time block, the
+// lines of a text chunk, or a picture layout.
+type pageBlock struct {
+	entry  int
+	top    float64
+	bottom float64
+	areas  [][][]float64
+}
+
+// justifyPages vertically justifies every page but the last page of each month: the
+// last page laid out, and pages whose last entry is of another month than the first
+// entry of the next page.
+func (e *ContinuousLayoutEngine) justifyPages() {
+	for p := 0; p < len(e.pages)-1; p++ {
+		if pageMonth(e.pages[p], true) != pageMonth(e.pages[p+1], false) {
+			continue
+		}
+		e.justifyPage(&e.pages[p])
+	}
+}
+
+// pageMonth returns the year and month of the last entry on page if last is set, of
+// the first entry otherwise. It is empty for pages without entries and for times that
+// cannot be parsed, which count as one month.
+func pageMonth(page ContinuousLayoutPage, last bool) string {
+	if len(page.Entries) == 0 {
+		return ""
+	}
+	entry := page.Entries[0]
+	if last {
+		entry = page.Entries[len(page.Entries)-1]
+	}
+	t, ok := parseEntryTime(entry.Time)
+	if !ok {
+		return ""
+	}
+	return t.Format("2006-01")
+}
+
+// justifyPage moves the content of page down so that the space left at its bottom is
+// shared by the gaps between blocks, in proportion to how much each gap may grow.
+// Gaps between entries grow up to MaxEntrySpacing and gaps within an entry up to
+// MaxElementSpacing; space they cannot take stays at the bottom. Areas in the top
+// margin (continuation headers) are not moved.
+func (e *ContinuousLayoutEngine) justifyPage(page *ContinuousLayoutPage) {
+	type ref struct {
+		entry int
+		area  [][]float64
+	}
+	var refs []ref
+	seen := make(map[*float64]bool)
+	add := func(entry int, area [][]float64) {
+		if len(area) != 2 || len(area[0]) != 2 || len(area[1]) != 2 || area[1][1] <= e.marginTop || seen[&area[0][1]] {
+			return
+		}
+		seen[&area[0][1]] = true
+		refs = append(refs, ref{entry, area})
+	}
+	for i := range page.Entries {
+		entry := &page.Entries[i]
+		add(i, entry.TimeArea)
+		for _, area := range entry.TextAreas {
+			add(i, area)
+		}
+		for _, run := range entry.InlineRuns {
+			add(i, run.Area)
+		}
+		for _, pic := range entry.Pictures {
+			add(i, pic.Area)
+		}
+	}
+	if len(refs) == 0 {
+		return
+	}
+	sort.SliceStable(refs, func(a, b int) bool { return refs[a].area[0][1] < refs[b].area[0][1] })
+
+	// Areas closer than the image spacing belong to one block: text lines touch and
+	// pictures of a layout are image_spacing apart.
+	var blocks []pageBlock
+	for _, r := range refs {
+		top, bottom := r.area[0][1], r.area[1][1]
+		if n := len(blocks); n > 0 && blocks[n-1].entry == r.entry && top <= blocks[n-1].bottom+e.imageSpacing+1e-6 {
+			blocks[n-1].bottom = math.Max(blocks[n-1].bottom, bottom)
+			blocks[n-1].areas = append(blocks[n-1].areas, r.area)
+			continue
+		}
+		blocks = append(blocks, pageBlock{entry: r.entry, top: top, bottom: bottom, areas: [][][]float64{r.area}})
+	}
+
+	maxEntry := e.config.VerticalJustification.MaxEntrySpacing * e.dpiScale
+	maxElement := e.config.VerticalJustification.MaxElementSpacing * e.dpiScale
+	room := make([]float64, len(blocks)) // how much the gap above block i may grow
+	totalRoom := 0.0
+	contentBottom := blocks[0].bottom
+	for i := 1; i < len(blocks); i++ {
+		limit := maxElement
+		if blocks[i].entry != blocks[i-1].entry {
+			limit = maxEntry
+		}
+		room[i] = math.Max(0, limit-(blocks[i].top-blocks[i-1].bottom))
+		totalRoom += room[i]
+		contentBottom = math.Max(contentBottom, blocks[i].bottom)
+	}
+	leftover := e.marginTop + e.availableHeight - contentBottom
+	if leftover <= 1e-6 || totalRoom <= 1e-6 {
+		return
+	}
+	share := math.Min(1, leftover/totalRoom)
+
+	shift := 0.0
+	for i, block := range blocks {
+		shift += room[i] * share
+		if shift == 0 {
+			continue
+		}
+		for _, area := range block.areas {
+			area[0][1] += shift
+			area[1][1] += shift
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package waterfall
+
+import (
+	"math"
+	"testing"
+)
+
+// justifyTestPage returns a page with two entries of the given time, their blocks
+// placed from top with the default element spacing within entries and gaps of 50
+// between them.
+func justifyTestPage(time string, top float64) ContinuousLayoutPage {
+	area := func(y0, y1 float64) [][]float64 { return [][]float64{{0, top + y0}, {100, top + y1}} }
+	return ContinuousLayoutPage{Entries: []PageEntry{
+		{Time: time, TimeArea: area(0, 100), TextAreas: [][][]float64{area(130, 170), area(170, 210)}},
+		{Time: time, TimeArea: area(260, 360), Pictures: []Picture{{Area: area(390, 800)}, {Area: area(815, 1200)}}},
+	}}
+}
+
+func justifyTestEngine() *ContinuousLayoutEngine {
+	cfg := DefaultLayoutConfig()
+	cfg.VerticalJustification.Enabled = true
+	e := NewContinuousLayoutEngineWithConfig(nil, cfg)
+	e.logger = discardLogger
+	return e
+}
+
+func TestJustifyPage(t *testing.T) {
+	e := justifyTestEngine()
+	maxEntry := e.config.VerticalJustification.MaxEntrySpacing * e.dpiScale
+	maxElement := e.config.VerticalJustification.MaxElementSpacing * e.dpiScale
+	bottom := e.marginTop + e.availableHeight
+
+	tests := []struct {
+		name string
+		top  float64 // of the content; the page holds 1200 pixels of it
+		full bool    // whether the gaps take all the space left
+	}{
+		{"gaps reach their maximum", e.marginTop, false},
+		{"page filled", bottom - 1200 - 100, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := justifyTestPage("2025-03-01 10:00:00", tt.top)
+			e.justifyPage(&page)
+
+			time0, text, time1, pics := page.Entries[0].TimeArea, page.Entries[0].TextAreas, page.Entries[1].TimeArea, page.Entries[1].Pictures
+			if time0[0][1] != tt.top {
+				t.Errorf("first block moved from %.2f to %.2f", tt.top, time0[0][1])
+			}
+			if text[1][0][1] != text[0][1][1] || pics[1].Area[0][1]-pics[0].Area[1][1] != 15 {
+				t.Error("areas of one block moved apart")
+			}
+			gaps := []struct {
+				gap, max float64
+			}{
+				{text[0][0][1] - time0[1][1], maxElement},
+				{time1[0][1] - text[1][1][1], maxEntry},
+				{pics[0].Area[0][1] - time1[1][1], maxElement},
+			}
+			for i, g := range gaps {
+				if g.gap > g.max+1e-6 {
+					t.Errorf("gap %d is %.2f, above its maximum %.2f", i, g.gap, g.max)
+				}
+				if !tt.full && math.Abs(g.gap-g.max) > 1e-6 {
+					t.Errorf("gap %d is %.2f, want its maximum %.2f", i, g.gap, g.max)
+				}
+			}
+			if end := pics[1].Area[1][1]; end > bottom+1e-6 || (tt.full && math.Abs(end-bottom) > 1e-6) {
+				t.Errorf("content ends at %.2f, page bottom is %.2f", end, bottom)
+			}
+		})
+	}
+}
+
+func TestJustifyPagesSkipsLastPageOfMonth(t *testing.T) {
+	e := justifyTestEngine()
+	e.pages = []ContinuousLayoutPage{
+		justifyTestPage("2025-03-01 10:00:00", e.marginTop),
+		justifyTestPage("2025-03-31 10:00:00", e.marginTop), // last page of March
+		justifyTestPage("2025-04-01 10:00:00", e.marginTop), // last page laid out
+	}
+	e.justifyPages()
+
+	for p, want := range []bool{true, false, false} {
+		moved := e.pages[p].Entries[1].TimeArea[0][1] != e.marginTop+260
+		if moved != want {
+			t.Errorf("page %d justified: %v, want %v", p, moved, want)
+		}
+	}
+}
@@ -5,6 +5,12 @@ go 1.21.0
 toolchain go1.24.1
 
 require (
-	filippo.io/edwards25519 v1.1.0 // indirect
 	github.com/go-sql-driver/mysql v1.9.2 // direct
+	golang.org/x/image v0.18.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	filippo.io/edwards25519 v1.1.0 // indirect
+	golang.org/x/text v0.16.0 // indirect
 )
@@ -0,0 +1,244 @@
+package main
+
+// The golden layout check: the sample moments in golden/moments.json are laid out with
+// every config in golden/configs and compared with the checked-in layouts in
+// golden/expected. After an intended layout change, rewrite the expected layouts with
+//
+//	go test -run TestGolden . -update
+//
+// Every config is laid out twice, so nondeterministic output is reported as well.
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"wechatmomenttypeset/backend/waterfall"
+)
+
+var update = flag.Bool("update", false, "rewrite the expected golden layouts instead of comparing")
+
+// corpus is the format of moments.json: entries grouped by month, as the server
+// lays them out.
+type corpus struct {
+	Months []struct {
+		Name    string            `json:"name"`
+		Entries []waterfall.Entry `json:"entries"`
+	} `json:"months"`
+}
+
+// monthLayout is the layout of one month in an expected file.
+type monthLayout struct {
+	Name  string                           `json:"name"`
+	Pages []waterfall.ContinuousLayoutPage `json:"pages"`
+}
+
+// tolerance is the largest difference between numbers that still counts as equal.
+// Coordinates are stored rounded to 0.01; this absorbs rounding at the last digit on
+// platforms with fused multiply-add.
+const tolerance = 0.015
+
+// maxReported is the number of differences listed per config.
+const maxReported = 10
+
+func TestGolden(t *testing.T) {
+	// Warnings about the sample pictures are expected; only the layouts matter here.
+	waterfall.SetLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	defer waterfall.SetLogger(nil)
+
+	data, err := os.ReadFile(filepath.Join("golden", "moments.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var moments corpus
+	if err := json.Unmarshal(data, &moments); err != nil {
+		t.Fatalf("moments.json: %v", err)
+	}
+
+	configs, err := filepath.Glob(filepath.Join("golden", "configs", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(configs)
+	if len(configs) == 0 {
+		t.Fatal("no configs in golden/configs")
+	}
+
+	for _, path := range configs {
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		t.Run(name, func(t *testing.T) {
+			got, err := layout(path, moments)
+			if err != nil {
+				t.Fatal(err)
+			}
+			again, err := layout(path, moments)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, again) {
+				t.Error("layout differs between two runs")
+			}
+
+			expectedPath := filepath.Join("golden", "expected", name+".json")
+			if *update {
+				if err := os.MkdirAll(filepath.Dir(expectedPath), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(expectedPath, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				t.Logf("updated %s", expectedPath)
+				return
+			}
+
+			expected, err := os.ReadFile(expectedPath)
+			if err != nil {
+				t.Fatalf("%v (run with -update to create it)", err)
+			}
+			diffs, err := compare(expected, got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, d := range diffs {
+				if i == maxReported {
+					t.Errorf("... %d more difference(s)", len(diffs)-maxReported)
+					break
+				}
+				t.Error(d)
+			}
+		})
+	}
+}
+
+// layout lays out every month of moments with the config at path, numbering pages
+// across months, and returns the result as indented JSON with numbers rounded to 0.01.
+func layout(path string, moments corpus) ([]byte, error) {
+	cfg, err := waterfall.LoadLayoutConfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var months []monthLayout
+	page := 1
+	for _, month := range moments.Months {
+		pages, err := waterfall.LayoutFromPage(month.Entries, cfg, page)
+		if err != nil {
+			return nil, fmt.Errorf("month %s: %w", month.Name, err)
+		}
+		page += len(pages)
+		months = append(months, monthLayout{Name: month.Name, Pages: pages})
+	}
+
+	raw, err := json.Marshal(months)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(raw, &generic); err != nil {
+		return nil, err
+	}
+	out, err := json.MarshalIndent(round(generic), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// round rounds every number in a decoded JSON value to two decimals.
+func round(v interface{}) interface{} {
+	switch v := v.(type) {
+	case float64:
+		return math.Round(v*100) / 100
+	case []interface{}:
+		for i := range v {
+			v[i] = round(v[i])
+		}
+	case map[string]interface{}:
+		for k := range v {
+			v[k] = round(v[k])
+		}
+	}
+	return v
+}
+
+// compare returns the differences between two layouts as "path: expected -> got".
+func compare(expected, got []byte) ([]string, error) {
+	var want, have interface{}
+	if err := json.Unmarshal(expected, &want); err != nil {
+		return nil, fmt.Errorf("expected layout: %w", err)
+	}
+	if err := json.Unmarshal(got, &have); err != nil {
+		return nil, err
+	}
+	var diffs []string
+	walk("$", want, have, &diffs)
+	return diffs, nil
+}
+
+func walk(path string, want, have interface{}, diffs *[]string) {
+	switch w := want.(type) {
+	case map[string]interface{}:
+		h, ok := have.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make(map[string]bool)
+		for k := range w {
+			keys[k] = true
+		}
+		for k := range h {
+			keys[k] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+		for _, k := range sorted {
+			walk(path+"."+k, w[k], h[k], diffs)
+		}
+		return
+	case []interface{}:
+		h, ok := have.([]interface{})
+		if !ok {
+			break
+		}
+		if len(w) != len(h) {
+			*diffs = append(*diffs, fmt.Sprintf("%s: %d elements -> %d", path, len(w), len(h)))
+		}
+		for i := 0; i < len(w) && i < len(h); i++ {
+			walk(fmt.Sprintf("%s[%d]", path, i), w[i], h[i], diffs)
+		}
+		return
+	case float64:
+		if h, ok := have.(float64); ok && math.Abs(w-h) <= tolerance {
+			return
+		}
+	default:
+		if fmt.Sprint(want) == fmt.Sprint(have) {
+			return
+		}
+	}
+	*diffs = append(*diffs, fmt.Sprintf("%s: %s -> %s", path, brief(want), brief(have)))
+}
+
+// brief formats a JSON value for a difference report.
+func brief(v interface{}) string {
+	if v == nil {
+		return "(missing)"
+	}
+	data, _ := json.Marshal(v)
+	if len(data) > 60 {
+		return string(data[:57]) + "..."
+	}
+	return string(data)
+}
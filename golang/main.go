@@ -1,13 +1,27 @@
 package main
 
 import (
+	"flag"
 	"log"
+	"log/slog"
 	"os"
 
 	"wechatmomenttypeset/backend"
+	"wechatmomenttypeset/backend/waterfall"
 )
 
 func main() {
+	configPath := flag.String("layout-config", "", "path to a JSON or YAML layout config file")
+	preset := flag.String("layout-preset", "", "name of a built-in layout preset (ignored when -layout-config is set)")
+	logLevel := flag.String("log-level", "info", "log level of the layout engine: debug, info, warn or error")
+	flag.Parse()
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(*logLevel)); err != nil {
+		log.Fatal("Invalid log level:", err)
+	}
+	waterfall.SetLogger(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level})))
+
 	// Get current working directory
 	basePath, err := os.Getwd()
 	if err != nil {
@@ -17,6 +31,24 @@ func main() {
 	// Create and start server
 	dbDSN := ""
 	server := backend.NewServer(8888, basePath, dbDSN)
+
+	// Load layout configuration
+	var cfg waterfall.LayoutConfig
+	switch {
+	case *configPath != "":
+		cfg, err = waterfall.LoadLayoutConfig(*configPath)
+	case *preset != "":
+		cfg, err = waterfall.LayoutConfigPreset(*preset)
+	default:
+		cfg = waterfall.DefaultLayoutConfig()
+	}
+	if err != nil {
+		log.Fatal("Error loading layout config:", err)
+	}
+	if err := server.SetLayoutConfig(cfg); err != nil {
+		log.Fatal("Invalid layout config:", err)
+	}
+
 	if err := server.Start(); err != nil {
 		log.Fatal("Error starting server:", err)
 	}